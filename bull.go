@@ -3,4 +3,43 @@ package bull
 type ContentSettings struct {
 	HardWraps           bool `toml:"hard_wraps"`
 	InteractiveTaskList bool `toml:"interactive_task_list"`
+
+	// Queries maps names to search queries, which bull renders
+	// as generated pages under /_bull/query/<name>.
+	Queries map[string]string `toml:"queries"`
+
+	// Aliases maps alternative page names to canonical page names,
+	// in addition to the aliases in the front matter of pages.
+	Aliases map[string]string `toml:"aliases"`
+
+	// MissingLinkClass is the CSS class of wiki links to pages which do
+	// not exist (yet). If empty, these links are rendered like all others.
+	MissingLinkClass string `toml:"missing_link_class"`
+
+	// RelativeLinks enables directory-aware resolution of wiki links:
+	// [[target]] refers to a page next to the linking page if one exists,
+	// otherwise to the page with the shortest name ending in target (if
+	// unique), and only otherwise to target in the content root.
+	RelativeLinks bool `toml:"relative_links"`
+
+	// Templates maps page name patterns (see path.Match, e.g. meetings/*)
+	// to template pages, whose content new pages start with. Without a
+	// matching pattern, new pages in a directory (e.g. meetings) use the
+	// template page in _templates (e.g. _templates/meetings), if any.
+	Templates map[string]string `toml:"templates"`
+
+	// DailyPage is the name of daily journal pages as a Go time layout
+	// (see time.Layout), e.g. days/2006-01-02. /_bull/today leads to the
+	// page of the current day. If empty (the default), bull has no journal.
+	DailyPage string `toml:"daily_page"`
+
+	// DailyTemplate is the name of the page whose content /_bull/today
+	// uses to create the page of the current day if it does not exist.
+	DailyTemplate string `toml:"daily_template"`
+
+	// TaskStates is the order in which clicking a task cycles through
+	// task states, e.g. [" ", "/", "x", "-"] for open, in progress, done
+	// and cancelled. Other recognized states are > (migrated) and
+	// < (scheduled). Tasks in states not listed here become open.
+	TaskStates []string `toml:"task_states"`
 }
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"errors"
 	"log"
+	"os"
 
 	"github.com/gokrazy/bull/internal/bull"
 )
 
 func main() {
 	if err := (&bull.Customization{}).Runbull(); err != nil {
-		log.Fatal(err)
+		if errors.Is(err, bull.ErrNoMatches) {
+			os.Exit(1) // like grep: no error, but nothing found
+		}
+		log.Print(err)
+		os.Exit(2)
 	}
 }
@@ -12,6 +12,7 @@ require (
 	golang.org/x/image v0.23.0
 	golang.org/x/sync v0.10.0
 	golang.org/x/tools v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
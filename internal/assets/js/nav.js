@@ -32,6 +32,11 @@ document.addEventListener('keydown', function(e) {
 	event.preventDefault();
 	navsearch.click();
     }
+    if (e.key == 'p') {
+	// C-p (switch to _p_age), like the quick open of many editors
+	event.preventDefault();
+	navSwitcherOpen();
+    }
     if (e.key == 'i') {
 	// C-i (index)
 	event.preventDefault();
@@ -39,3 +44,102 @@ document.addEventListener('keydown', function(e) {
     }
 });
 
+
+// The page switcher offers fuzzy page name matches as you type. Pressing
+// Enter without a selected match searches for the input instead.
+const navbar = document.getElementById('bull_navbar');
+const navURLPrefix = navbar.dataset.urlPrefix;
+const navURLBullPrefix = navbar.dataset.urlBullPrefix;
+let navSwitcher = null;
+
+function navPageURL(pageName) {
+    return navURLPrefix + pageName.split('/').map(encodeURIComponent).join('/');
+}
+
+function navSwitcherOpen() {
+    if (navSwitcher === null) {
+	navSwitcher = navSwitcherCreate();
+    }
+    navSwitcher.input.value = '';
+    navSwitcher.update();
+    navSwitcher.dialog.showModal();
+    navSwitcher.input.focus();
+}
+
+function navSwitcherCreate() {
+    const dialog = document.createElement('dialog');
+    dialog.id = 'bull_switcher';
+    const input = document.createElement('input');
+    input.type = 'search';
+    input.placeholder = 'go to page (enter to search)';
+    input.autocomplete = 'off';
+    const list = document.createElement('ul');
+    dialog.append(input, list);
+    document.body.append(dialog);
+
+    let matches = [];
+    let selected = 0;
+    let generation = 0;
+
+    function render() {
+	list.replaceChildren(...matches.map((m, idx) => {
+	    const li = document.createElement('li');
+	    const a = document.createElement('a');
+	    a.href = navPageURL(m.page_name);
+	    const positions = new Set(m.positions);
+	    Array.from(m.page_name).forEach((ch, pos) => {
+		if (positions.has(pos)) {
+		    const mark = document.createElement('mark');
+		    mark.textContent = ch;
+		    a.append(mark);
+		} else {
+		    a.append(ch);
+		}
+	    });
+	    if (idx == selected) {
+		li.className = 'selected';
+	    }
+	    li.append(a);
+	    return li;
+	}));
+    }
+
+    async function update() {
+	const gen = ++generation;
+	const u = navURLBullPrefix + '_pages?limit=10&q=' + encodeURIComponent(input.value);
+	const resp = await fetch(u);
+	if (!resp.ok || gen != generation) {
+	    return; // error, or a newer request is in flight
+	}
+	matches = await resp.json();
+	selected = 0;
+	render();
+    }
+
+    input.addEventListener('input', update);
+    input.addEventListener('keydown', function(e) {
+	if (e.key == 'ArrowDown' || e.key == 'ArrowUp') {
+	    e.preventDefault();
+	    if (matches.length > 0) {
+		const delta = (e.key == 'ArrowDown' ? 1 : -1);
+		selected = (selected + delta + matches.length) % matches.length;
+		render();
+	    }
+	}
+	if (e.key == 'Enter') {
+	    e.preventDefault();
+	    if (matches.length > 0 && input.value != '') {
+		window.location.href = navPageURL(matches[selected].page_name);
+	    } else {
+		window.location.href = navsearch.href + '?q=' + encodeURIComponent(input.value);
+	    }
+	}
+    });
+    dialog.addEventListener('click', function(e) {
+	if (e.target === dialog) {
+	    dialog.close(); // click on the backdrop
+	}
+    });
+
+    return {dialog, input, update};
+}
@@ -0,0 +1,83 @@
+package bull
+
+import (
+	"slices"
+)
+
+// resolveAlias returns the canonical page name for name if name is an alias,
+// defined in the front matter of the canonical page (aliases: [...]) or in
+// the [aliases] table of content-settings.toml. Existing pages take
+// precedence over aliases.
+func (b *bullServer) resolveAlias(idx *idx, name string) (string, bool) {
+	if _, ok := idx.links[name]; ok {
+		return "", false // name is a page
+	}
+	canonical, ok := b.contentSettings.Aliases[name]
+	if !ok && idx.text != nil {
+		canonical, ok = idx.text.aliases[name]
+	}
+	if !ok || canonical == name {
+		return "", false
+	}
+	return canonical, true
+}
+
+// aliasesOf returns the (sorted) aliases which resolve to pageName.
+func (b *bullServer) aliasesOf(idx *idx, pageName string) []string {
+	var candidates []string
+	if idx.text != nil {
+		if doc, ok := idx.text.docs[pageName]; ok {
+			candidates = append(candidates, doc.aliases()...)
+		}
+	}
+	for alias, canonical := range b.contentSettings.Aliases {
+		if canonical == pageName {
+			candidates = append(candidates, alias)
+		}
+	}
+	var aliases []string
+	for _, alias := range candidates {
+		if canonical, ok := b.resolveAlias(idx, alias); ok && canonical == pageName {
+			aliases = append(aliases, alias)
+		}
+	}
+	slices.Sort(aliases)
+	return slices.Compact(aliases)
+}
+
+// backlinksTo returns the (sorted) names of pages linking to pageName,
+// either directly or via one of its aliases.
+func (b *bullServer) backlinksTo(idx *idx, pageName string) []string {
+	linkers := idx.backlinks[pageName]
+	aliases := b.aliasesOf(idx, pageName)
+	if len(aliases) == 0 {
+		return linkers
+	}
+	linkers = slices.Clone(linkers)
+	for _, alias := range aliases {
+		linkers = append(linkers, idx.backlinks[alias]...)
+	}
+	slices.Sort(linkers)
+	return slices.Compact(linkers)
+}
+
+// backlinkContexts returns the text surrounding the links from linker to
+// pageName (or one of its aliases), see pageRefs.
+func (b *bullServer) backlinkContexts(idx *idx, linker, pageName string) []string {
+	if idx.text == nil {
+		return nil
+	}
+	doc, ok := idx.text.docs[linker]
+	if !ok {
+		return nil
+	}
+	contexts := doc.contexts[pageName]
+	for _, alias := range b.aliasesOf(idx, pageName) {
+		for _, snippet := range doc.contexts[alias] {
+			if !slices.Contains(contexts, snippet) {
+				contexts = append(slices.Clip(contexts), snippet)
+			}
+		}
+	}
+	return contexts
+}
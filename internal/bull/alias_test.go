@@ -0,0 +1,118 @@
+package bull
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAliases(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"_bull/content-settings.toml": `
+[aliases]
+"old/name" = "projects/bull"
+`,
+		"projects/bull.md": "---\naliases: [bull, the wiki, taken]\n---\nbull is a wiki\n",
+		"index.md":         "see [[bull]] and [[the wiki]]",
+		"notes.md":         "renamed: [[old/name]], missing: [[nowhere]]",
+		"taken.md":         "a page, not an alias",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	t.Run("Resolve", func(t *testing.T) {
+		for _, tt := range []struct {
+			name, want string
+			ok         bool
+		}{
+			{name: "bull", want: "projects/bull", ok: true},
+			{name: "the wiki", want: "projects/bull", ok: true},
+			{name: "old/name", want: "projects/bull", ok: true},
+			{name: "taken"}, // existing pages take precedence
+			{name: "nowhere"},
+		} {
+			got, ok := b.resolveAlias(idx, tt.name)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("resolveAlias(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+			}
+		}
+	})
+
+	t.Run("Backlinks", func(t *testing.T) {
+		if diff := cmp.Diff([]string{"index", "notes"}, b.backlinksTo(idx, "projects/bull")); diff != "" {
+			t.Errorf("backlinksTo(projects/bull): unexpected diff (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Graph", func(t *testing.T) {
+		out := b.analyzeGraph(idx)
+		want := []brokenLink{{Source: "notes", Target: "nowhere"}}
+		if diff := cmp.Diff(want, out.BrokenLinks); diff != "" {
+			t.Errorf("BrokenLinks mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]string{"notes", "taken"}, out.Orphans); diff != "" {
+			t.Errorf("Orphans mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Redirect", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.Handle("/{page...}", handleError(b.handleRender))
+		testsrv := httptest.NewServer(mux)
+		defer testsrv.Close()
+		client := testsrv.Client()
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+
+		resp, err := client.Get(testsrv.URL + "/the%20wiki")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got, want := resp.StatusCode, http.StatusFound; got != want {
+			t.Fatalf("GET /the%%20wiki: unexpected HTTP status: got %v, want %v", got, want)
+		}
+		if got, want := resp.Header.Get("Location"), "/projects/bull"; got != want {
+			t.Errorf("GET /the%%20wiki: unexpected redirect: got %q, want %q", got, want)
+		}
+
+		resp, err = client.Get(testsrv.URL + "/projects/bull")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `<a href="/notes">notes</a>`; !strings.Contains(string(body), want) {
+			t.Errorf("GET /projects/bull: backlinks do not contain %q", want)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		b.indexPage(&page{
+			PageName: "projects/bull",
+			FileName: "projects/bull.md",
+			Content:  "no more aliases",
+			ModTime:  time.Now(),
+		}, &pageRefs{})
+		idx := b.idx.Load()
+		if got, ok := b.resolveAlias(idx, "bull"); ok {
+			t.Errorf("resolveAlias(bull) = %q after removing the alias", got)
+		}
+		// aliases from content-settings.toml are unaffected
+		if _, ok := b.resolveAlias(idx, "old/name"); !ok {
+			t.Errorf("resolveAlias(old/name) unexpectedly failed")
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package bull
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+)
+
+// blockIDRegexp matches a block ID marker (like ^shopping-list) at the end
+// of the last line of a paragraph or list item.
+var blockIDRegexp = regexp.MustCompile(`(?:^|\s)\^([A-Za-z0-9][A-Za-z0-9-]*)$`)
+
+// blockID returns the block ID (with ^ prefix) of the block n,
+// or the empty string if n has no block ID marker.
+func blockID(n ast.Node, source []byte) string {
+	lines := n.Lines()
+	if lines.Len() == 0 {
+		return ""
+	}
+	last := lines.At(lines.Len() - 1)
+	m := blockIDRegexp.FindSubmatch(bytes.TrimSpace(last.Value(source)))
+	if m == nil {
+		return ""
+	}
+	return "^" + string(m[1])
+}
+
+// renderBlockIDs sets the HTML id of each block with a block ID marker (see
+// blockID), so that links to page#^block-id work, and removes the marker
+// from the rendered text. In tight lists, the id is set on the list item.
+func renderBlockIDs(doc ast.Node, source []byte) {
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.(type) {
+		case *ast.Paragraph, *ast.TextBlock:
+		default:
+			return ast.WalkContinue, nil
+		}
+		id := blockID(n, source)
+		if id == "" {
+			return ast.WalkSkipChildren, nil
+		}
+		target := n
+		if _, ok := n.(*ast.TextBlock); ok {
+			if _, ok := n.Parent().(*ast.ListItem); !ok {
+				return ast.WalkSkipChildren, nil
+			}
+			target = n.Parent()
+		}
+		target.SetAttributeString("id", []byte(id))
+
+		// Remove the marker (and the whitespace before it)
+		// from the text at the end of the block.
+		last := n.Lines().At(n.Lines().Len() - 1)
+		loc := blockIDRegexp.FindIndex(bytes.TrimRight(last.Value(source), " \t\r\n"))
+		if loc == nil {
+			return ast.WalkSkipChildren, nil
+		}
+		markerStart := last.Start + loc[0]
+		for c := n.LastChild(); c != nil; c = n.LastChild() {
+			t, ok := c.(*ast.Text)
+			if !ok {
+				break
+			}
+			if t.Segment.Start >= markerStart {
+				n.RemoveChild(n, t)
+				continue
+			}
+			if t.Segment.Stop > markerStart {
+				t.Segment = t.Segment.WithStop(markerStart)
+			}
+			break
+		}
+		return ast.WalkSkipChildren, nil
+	})
+}
+
+// headingID returns the ID which parser.WithAutoHeadingID generates for the
+// (first) heading with the given text.
+func headingID(text string) string {
+	return string(parser.NewContext().IDs().Generate([]byte(text), ast.KindHeading))
+}
+
+// hasAnchor reports whether fragment refers to one of anchors (heading IDs
+// and ^block IDs, sorted). Like with embeds (see section), fragments can
+// also be written as heading text, e.g. [[bull#Getting started]].
+func hasAnchor(anchors []string, fragment string) bool {
+	if _, found := slices.BinarySearch(anchors, fragment); found {
+		return true
+	}
+	if strings.HasPrefix(fragment, "^") {
+		return false
+	}
+	_, found := slices.BinarySearch(anchors, headingID(fragment))
+	return found
+}
+
+// sameAnchor reports whether the fragments a and b refer to the same
+// heading (or ^block ID).
+func sameAnchor(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+		return false
+	}
+	return headingID(a) == headingID(b)
+}
+
+// anchorID returns the HTML ID of the element which fragment refers to.
+func anchorID(anchors []string, fragment string) string {
+	if _, found := slices.BinarySearch(anchors, fragment); found || strings.HasPrefix(fragment, "^") {
+		return fragment
+	}
+	return headingID(fragment)
+}
+
+// backlinkSections returns the (sorted) fragments of links from linker to
+// pageName (or one of its aliases), i.e. which sections linker refers to.
+func (b *bullServer) backlinkSections(idx *idx, linker, pageName string) []string {
+	if idx.text == nil {
+		return nil
+	}
+	doc, ok := idx.text.docs[linker]
+	if !ok {
+		return nil
+	}
+	sections := slices.Clone(doc.fragments[pageName])
+	for _, alias := range b.aliasesOf(idx, pageName) {
+		sections = append(sections, doc.fragments[alias]...)
+	}
+	slices.Sort(sections)
+	return slices.Compact(sections)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"cmp"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"path"
@@ -12,6 +13,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/gokrazy/bull/internal/frontmatter"
 )
 
 type browse struct {
@@ -21,6 +24,9 @@ type browse struct {
 	sortorder   string
 	directories string
 	pages       []page
+	// meta returns the front matter of a page (nil if none),
+	// for sorting by a front matter key (?sort=meta:date).
+	meta func(pageName string) *frontmatter.FrontMatter
 }
 
 func (br *browse) prefix() string {
@@ -69,6 +75,30 @@ func (br *browse) sortPages() error {
 		return fmt.Errorf("unknown sortorder %q", br.sortorder)
 	}
 
+	if key, ok := strings.CutPrefix(br.sortby, "meta:"); ok {
+		desc := br.sortorder == "desc"
+		slices.SortStableFunc(br.pages, func(a, b page) int {
+			av, aok := br.meta(a.PageName).Field(key)
+			bv, bok := br.meta(b.PageName).Field(key)
+			if aok != bok {
+				// pages without the key are listed last, in either order
+				if aok {
+					return -1
+				}
+				return 1
+			}
+			c := strings.Compare(av, bv)
+			if desc {
+				c = -c
+			}
+			return cmp.Or(
+				c,
+				cmp.Compare(a.PageName, b.PageName), // ascending tiebreaker
+			)
+		})
+		return nil
+	}
+
 	switch br.sortby {
 	case "modtime":
 		if br.sortorder == "desc" {
@@ -143,6 +173,36 @@ func browseTableLine(name string, modTime time.Time) string {
 	return fmt.Sprintf("| %s | %s |\n", name, ts)
 }
 
+// writeRecentTable writes a table of pageNames to w, most recently modified
+// pages first (like a dashboard). Unlike ordering by relevance, this keeps
+// the table (and hence the page hash) stable until content changes.
+func writeRecentTable(w io.Writer, text *textIndex, pageNames []string) {
+	if len(pageNames) == 0 {
+		return
+	}
+	modTime := func(pageName string) time.Time {
+		if text == nil {
+			return time.Time{}
+		}
+		if doc, ok := text.docs[pageName]; ok {
+			return doc.modTime
+		}
+		return time.Time{}
+	}
+	pageNames = slices.Clone(pageNames)
+	slices.SortFunc(pageNames, func(a, b string) int {
+		if c := modTime(b).Compare(modTime(a)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	fmt.Fprintf(w, "| page name | last modified |\n")
+	fmt.Fprintf(w, "|-----------|---------------|\n")
+	for _, pageName := range pageNames {
+		io.WriteString(w, browseTableLine("[["+pageName+"]]", modTime(pageName)))
+	}
+}
+
 func (br *browse) browseTable() []string {
 	dirs := br.dirs()
 	lines := make([]string, 0, len(br.pages))
@@ -162,7 +222,13 @@ func (br *browse) browseTable() []string {
 			}
 		}
 
-		lines = append(lines, browseTableLine("[["+pg.PageName+"]]", pg.ModTime))
+		name := "[[" + pg.PageName + "]]"
+		if key, ok := strings.CutPrefix(br.sortby, "meta:"); ok {
+			if value, ok := br.meta(pg.PageName).Field(key); ok {
+				name += " • " + tableCell(key) + ": " + tableCell(value)
+			}
+		}
+		lines = append(lines, browseTableLine(name, pg.ModTime))
 	}
 	return lines
 }
@@ -194,6 +260,17 @@ func (b *bullServer) browseContent(dir, sortby, sortorder, directories string) (
 		sortorder:   sortorder,
 		directories: directories,
 		pages:       pages,
+		meta: func(pageName string) *frontmatter.FrontMatter {
+			<-b.idxReady
+			text := b.idx.Load().text
+			if text == nil {
+				return nil
+			}
+			if doc, ok := text.docs[pageName]; ok {
+				return doc.meta
+			}
+			return nil
+		},
 	}
 	br.maybeFilterFilePrefix()
 	if err := br.sortPages(); err != nil {
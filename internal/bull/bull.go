@@ -10,6 +10,7 @@ import (
 
 	"github.com/BurntSushi/toml"
 	"github.com/gokrazy/bull"
+	"github.com/gokrazy/bull/internal/itasklist"
 	"github.com/yuin/goldmark"
 )
 
@@ -30,6 +31,8 @@ func loadContentSettings(content *os.Root) (bull.ContentSettings, error) {
 	cs := bull.ContentSettings{
 		HardWraps:           true, // like SilverBullet
 		InteractiveTaskList: true,
+		MissingLinkClass:    "bull_missing",
+		TaskStates:          itasklist.DefaultStates,
 	}
 	csf, err := content.Open("_bull/content-settings.toml")
 	if err != nil {
@@ -47,6 +50,11 @@ func loadContentSettings(content *os.Root) (bull.ContentSettings, error) {
 	if err := toml.Unmarshal(csb, &cs); err != nil {
 		return cs, err
 	}
+	for _, state := range cs.TaskStates {
+		if len(state) != 1 || itasklist.StateNames[state[0]] == "" {
+			return cs, fmt.Errorf("%s: invalid task state %q in task_states", csf.Name(), state)
+		}
+	}
 	log.Printf("bull content settings loaded from %s", csf.Name())
 	return cs, nil
 }
@@ -101,9 +109,11 @@ To get help on any verb, use bull <verb> --help or bull help <verb>.
 If no verb is specified, bull will default to 'serve'.
 
 Verbs:
-  serve  - serve markdown pages
-  mv     - rename markdown page and update links
-  graph  - show link graph, orphans, and broken links
+  serve   - serve markdown pages
+  mv      - rename markdown page and update links
+  graph   - show link graph, orphans, and broken links
+  search  - search pages from the command line
+  migrate - migrate open tasks of previous daily pages to today's page
 
 Examples:
   % bull                                # serve the current directory
@@ -140,6 +150,10 @@ Global command-line flags:
 		return mv(args)
 	case "graph":
 		return graph(args)
+	case "search":
+		return search(args)
+	case "migrate":
+		return migrate(args)
 	}
 	fmt.Fprintf(os.Stderr, "unknown verb %q\n", verb)
 	flag.Usage()
@@ -1,6 +1,7 @@
 package bull
 
 import (
+	"cmp"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -15,12 +16,19 @@ const graphUsage = `
 graph - show link graph, orphans, and broken links
 
 Syntax:
-  % bull graph [--output=text|json]
+  % bull graph [--output=text|json|dot|graphml|mermaid] [--root=<page> [--depth=N]]
+
+The dot, graphml and mermaid formats export the link graph between pages
+(or only the pages at most --depth links away from the --root page)
+for viewing with other tools.
 
 Examples:
   % bull --content ~/keep graph
   % bull --content ~/keep graph --output=json
   % bull --content ~/keep graph --output=json | jq .stats
+  % bull --content ~/keep graph --output=dot | dot -Tsvg > graph.svg
+  % bull --content ~/keep graph --output=graphml > graph.graphml
+  % bull --content ~/keep graph --output=mermaid --root=projects/bull --depth=2
 `
 
 type graphOutput struct {
@@ -38,6 +46,16 @@ type graphPage struct {
 type brokenLink struct {
 	Source string `json:"source"`
 	Target string `json:"target"`
+	// Fragment is set for links to a section (heading or ^block ID)
+	// which does not exist on the (existing) target page.
+	Fragment string `json:"fragment,omitempty"`
+}
+
+func (bl brokenLink) String() string {
+	if bl.Fragment != "" {
+		return bl.Target + "#" + bl.Fragment
+	}
+	return bl.Target
 }
 
 type graphStats struct {
@@ -50,7 +68,9 @@ type graphStats struct {
 func graph(args []string) error {
 	fset := flag.NewFlagSet("graph", flag.ExitOnError)
 	fset.Usage = usage(fset, graphUsage)
-	output := fset.String("output", "text", "output format: text or json")
+	output := fset.String("output", "text", "output format: text, json, dot, graphml or mermaid")
+	root := fset.String("root", "", "only export the neighborhood of this page (dot, graphml and mermaid output)")
+	depth := fset.Int("depth", 1, "with --root, export pages at most this many links away")
 
 	if err := fset.Parse(args); err != nil {
 		return err
@@ -83,6 +103,70 @@ func graph(args []string) error {
 	}
 	elapsed := time.Since(start)
 
+	switch *output {
+	case "dot", "graphml", "mermaid":
+		g, err := bull.linkGraph(idx, *root, *depth)
+		if err != nil {
+			return err
+		}
+		switch *output {
+		case "dot":
+			return g.writeDOT(os.Stdout)
+		case "graphml":
+			return g.writeGraphML(os.Stdout)
+		default:
+			return g.writeMermaid(os.Stdout, nil)
+		}
+	}
+	if *root != "" {
+		return fmt.Errorf("--root is only supported with --output=dot, graphml or mermaid")
+	}
+
+	out := bull.analyzeGraph(idx)
+	stats := out.Stats
+
+	switch *output {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+
+	case "text":
+		log.Printf("indexed %d pages (%d links) in %.2fs\n", idx.pages, stats.TotalLinks, elapsed.Seconds())
+
+		if len(out.Orphans) > 0 {
+			fmt.Println()
+			fmt.Println("orphan pages (no incoming links):")
+			for _, o := range out.Orphans {
+				fmt.Printf("  %s\n", o)
+			}
+		}
+
+		if len(out.BrokenLinks) > 0 {
+			fmt.Println()
+			fmt.Println("broken links (target or section does not exist):")
+			for _, bl := range out.BrokenLinks {
+				fmt.Printf("  %s → %s\n", bl.Source, bl)
+			}
+		}
+
+		fmt.Println()
+		fmt.Println("graph summary:")
+		fmt.Printf("  pages:        %d\n", stats.TotalPages)
+		fmt.Printf("  links:        %d\n", stats.TotalLinks)
+		fmt.Printf("  orphans:      %d\n", stats.OrphanCount)
+		fmt.Printf("  broken links: %d\n", stats.BrokenLinkCount)
+
+	default:
+		return fmt.Errorf("unknown output format %q (supported: text, json, dot, graphml, mermaid)", *output)
+	}
+
+	return nil
+}
+
+// analyzeGraph finds orphans and broken links in idx. Links to aliases (see
+// resolveAlias) count as links to the canonical page.
+func (b *bullServer) analyzeGraph(idx *idx) graphOutput {
 	// Compute total links
 	totalLinks := 0
 	for _, targets := range idx.links {
@@ -95,7 +179,7 @@ func graph(args []string) error {
 		if pageName == "index" {
 			continue
 		}
-		if len(idx.backlinks[pageName]) == 0 {
+		if len(b.backlinksTo(idx, pageName)) == 0 {
 			orphans = append(orphans, pageName)
 		}
 	}
@@ -115,81 +199,71 @@ func graph(args []string) error {
 			if target == "" {
 				continue
 			}
-			if _, exists := idx.links[target]; !exists {
-				broken = append(broken, brokenLink{Source: source, Target: target})
+			if _, exists := idx.links[target]; exists {
+				continue
 			}
+			if _, ok := b.resolveAlias(idx, target); ok {
+				continue
+			}
+			broken = append(broken, brokenLink{Source: source, Target: target})
 		}
 	}
-	slices.SortFunc(broken, func(a, b brokenLink) int {
-		if c := strings.Compare(a.Source, b.Source); c != 0 {
-			return c
+	// Find broken section links (target exists, but section does not)
+	if idx.text != nil {
+		for source, doc := range idx.text.docs {
+			for target, fragments := range doc.fragments {
+				canonical := target
+				if resolved, ok := b.resolveAlias(idx, target); ok {
+					canonical = resolved
+				}
+				targetDoc, ok := idx.text.docs[canonical]
+				if !ok {
+					continue // broken link, see above
+				}
+				for _, fragment := range fragments {
+					if !hasAnchor(targetDoc.anchors, fragment) {
+						broken = append(broken, brokenLink{
+							Source:   source,
+							Target:   target,
+							Fragment: fragment,
+						})
+					}
+				}
+			}
 		}
-		return strings.Compare(a.Target, b.Target)
-	})
-
-	stats := graphStats{
-		TotalPages:      int(idx.pages),
-		TotalLinks:      totalLinks,
-		OrphanCount:     len(orphans),
-		BrokenLinkCount: len(broken),
 	}
+	slices.SortFunc(broken, func(a, b brokenLink) int {
+		return cmp.Or(
+			strings.Compare(a.Source, b.Source),
+			strings.Compare(a.Target, b.Target),
+			strings.Compare(a.Fragment, b.Fragment))
+	})
 
-	switch *output {
-	case "json":
-		pages := make(map[string]graphPage, len(idx.links))
-		for pageName, targets := range idx.links {
-			outgoing := targets
-			if outgoing == nil {
-				outgoing = make([]string, 0)
-			}
-			incoming := idx.backlinks[pageName]
-			if incoming == nil {
-				incoming = make([]string, 0)
-			}
-			pages[pageName] = graphPage{
-				Outgoing: outgoing,
-				Incoming: incoming,
-			}
+	pages := make(map[string]graphPage, len(idx.links))
+	for pageName, targets := range idx.links {
+		outgoing := targets
+		if outgoing == nil {
+			outgoing = make([]string, 0)
 		}
-		out := graphOutput{
-			Pages:       pages,
-			Orphans:     orphans,
-			BrokenLinks: broken,
-			Stats:       stats,
-		}
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		return enc.Encode(out)
-
-	case "text":
-		log.Printf("indexed %d pages (%d links) in %.2fs\n", idx.pages, totalLinks, elapsed.Seconds())
-
-		if len(orphans) > 0 {
-			fmt.Println()
-			fmt.Println("orphan pages (no incoming links):")
-			for _, o := range orphans {
-				fmt.Printf("  %s\n", o)
-			}
+		incoming := b.backlinksTo(idx, pageName)
+		if incoming == nil {
+			incoming = make([]string, 0)
 		}
-
-		if len(broken) > 0 {
-			fmt.Println()
-			fmt.Println("broken links (target does not exist):")
-			for _, bl := range broken {
-				fmt.Printf("  %s → %s\n", bl.Source, bl.Target)
-			}
+		pages[pageName] = graphPage{
+			Outgoing: outgoing,
+			Incoming: incoming,
 		}
-
-		fmt.Println()
-		fmt.Println("graph summary:")
-		fmt.Printf("  pages:        %d\n", stats.TotalPages)
-		fmt.Printf("  links:        %d\n", stats.TotalLinks)
-		fmt.Printf("  orphans:      %d\n", stats.OrphanCount)
-		fmt.Printf("  broken links: %d\n", stats.BrokenLinkCount)
-
-	default:
-		return fmt.Errorf("unknown output format %q (supported: text, json)", *output)
 	}
 
-	return nil
+	return graphOutput{
+		Pages:       pages,
+		Orphans:     orphans,
+		BrokenLinks: broken,
+		Stats: graphStats{
+			TotalPages:      int(idx.pages),
+			TotalLinks:      totalLinks,
+			OrphanCount:     len(orphans),
+			BrokenLinkCount: len(broken),
+		},
+	}
 }
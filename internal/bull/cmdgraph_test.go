@@ -2,96 +2,12 @@ package bull
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 )
 
-// graphAnalysis runs the same orphan/broken-link logic as the graph command
-// against a pre-built index, returning the structured output.
-func graphAnalysis(idx *idx) graphOutput {
-	totalLinks := 0
-	for _, targets := range idx.links {
-		totalLinks += len(targets)
-	}
-
-	orphans := make([]string, 0)
-	for pageName := range idx.links {
-		if pageName == "index" {
-			continue
-		}
-		if len(idx.backlinks[pageName]) == 0 {
-			orphans = append(orphans, pageName)
-		}
-	}
-
-	broken := make([]brokenLink, 0)
-	for source, targets := range idx.links {
-		for _, target := range targets {
-			if containsScheme(target) {
-				continue
-			}
-			if i := indexByte(target, '#'); i >= 0 {
-				target = target[:i]
-			}
-			if target == "" {
-				continue
-			}
-			if _, exists := idx.links[target]; !exists {
-				broken = append(broken, brokenLink{Source: source, Target: target})
-			}
-		}
-	}
-
-	pages := make(map[string]graphPage, len(idx.links))
-	for pageName, targets := range idx.links {
-		outgoing := targets
-		if outgoing == nil {
-			outgoing = make([]string, 0)
-		}
-		incoming := idx.backlinks[pageName]
-		if incoming == nil {
-			incoming = make([]string, 0)
-		}
-		pages[pageName] = graphPage{
-			Outgoing: outgoing,
-			Incoming: incoming,
-		}
-	}
-
-	return graphOutput{
-		Pages:       pages,
-		Orphans:     orphans,
-		BrokenLinks: broken,
-		Stats: graphStats{
-			TotalPages:      int(idx.pages),
-			TotalLinks:      totalLinks,
-			OrphanCount:     len(orphans),
-			BrokenLinkCount: len(broken),
-		},
-	}
-}
-
-// containsScheme checks for "://" in the target (same as graph command).
-func containsScheme(s string) bool {
-	for i := 0; i+2 < len(s); i++ {
-		if s[i] == ':' && s[i+1] == '/' && s[i+2] == '/' {
-			return true
-		}
-	}
-	return false
-}
-
-// indexByte returns the index of the first instance of c in s, or -1.
-func indexByte(s string, c byte) int {
-	for i := range len(s) {
-		if s[i] == c {
-			return i
-		}
-	}
-	return -1
-}
-
 func TestGraphEmptyGarden(t *testing.T) {
 	b := newTestBull(t, map[string]string{})
 
@@ -100,7 +16,7 @@ func TestGraphEmptyGarden(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	out := graphAnalysis(idx)
+	out := b.analyzeGraph(idx)
 
 	if out.Stats.TotalPages != 0 {
 		t.Errorf("TotalPages = %d, want 0", out.Stats.TotalPages)
@@ -125,7 +41,7 @@ func TestGraphOrphans(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	out := graphAnalysis(idx)
+	out := b.analyzeGraph(idx)
 
 	if diff := cmp.Diff([]string{"orphan"}, out.Orphans); diff != "" {
 		t.Errorf("Orphans mismatch (-want +got):\n%s", diff)
@@ -145,7 +61,7 @@ func TestGraphIndexExcludedFromOrphans(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	out := graphAnalysis(idx)
+	out := b.analyzeGraph(idx)
 
 	if len(out.Orphans) != 0 {
 		t.Errorf("index should not be an orphan, got Orphans = %v", out.Orphans)
@@ -163,7 +79,7 @@ func TestGraphBrokenLinks(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	out := graphAnalysis(idx)
+	out := b.analyzeGraph(idx)
 
 	want := []brokenLink{{Source: "index", Target: "missing"}}
 	if diff := cmp.Diff(want, out.BrokenLinks); diff != "" {
@@ -171,6 +87,45 @@ func TestGraphBrokenLinks(t *testing.T) {
 	}
 }
 
+func TestGraphBrokenSectionLinks(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"index.md": strings.Join([]string{
+			"# Overview",
+			"",
+			"see [[guide#Getting started]], [[guide#getting-started]],",
+			"[[guide#gone]], [[guide#^step-1]], [[guide#^step-2]],",
+			"[setup](guide#setup), [[#overview]] and [[#missing]]",
+		}, "\n"),
+		"guide.md": strings.Join([]string{
+			"# Getting started",
+			"",
+			"install bull ^step-1",
+			"",
+			"## Setup",
+		}, "\n"),
+	})
+
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"^step-1", "getting-started", "setup"}, idx.text.docs["guide"].anchors); diff != "" {
+		t.Errorf("anchors mismatch (-want +got):\n%s", diff)
+	}
+
+	out := b.analyzeGraph(idx)
+
+	want := []brokenLink{
+		{Source: "index", Target: "guide", Fragment: "^step-2"},
+		{Source: "index", Target: "guide", Fragment: "gone"},
+		{Source: "index", Target: "index", Fragment: "missing"},
+	}
+	if diff := cmp.Diff(want, out.BrokenLinks); diff != "" {
+		t.Errorf("BrokenLinks mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestGraphExternalLinksNotBroken(t *testing.T) {
 	b := newTestBull(t, map[string]string{
 		"index.md": "[ext](https://example.com) and [ext2](http://foo.bar)",
@@ -181,7 +136,7 @@ func TestGraphExternalLinksNotBroken(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	out := graphAnalysis(idx)
+	out := b.analyzeGraph(idx)
 
 	if len(out.BrokenLinks) != 0 {
 		t.Errorf("external links should not be broken, got %v", out.BrokenLinks)
@@ -190,8 +145,9 @@ func TestGraphExternalLinksNotBroken(t *testing.T) {
 
 func TestGraphFragmentLinksStripped(t *testing.T) {
 	b := newTestBull(t, map[string]string{
-		"index.md": "see [section](other#heading) and [anchor](#local)",
-		"other.md": "hello",
+		// The sections exist, see TestGraphBrokenSectionLinks for missing ones.
+		"index.md": "# local\n\nsee [section](other#heading) and [anchor](#local)",
+		"other.md": "# heading\n\nhello",
 	})
 
 	idx, err := b.index()
@@ -199,7 +155,7 @@ func TestGraphFragmentLinksStripped(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	out := graphAnalysis(idx)
+	out := b.analyzeGraph(idx)
 
 	if len(out.BrokenLinks) != 0 {
 		t.Errorf("fragment links should not be broken, got %v", out.BrokenLinks)
@@ -218,7 +174,7 @@ func TestGraphStats(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	out := graphAnalysis(idx)
+	out := b.analyzeGraph(idx)
 
 	if out.Stats.TotalPages != 3 {
 		t.Errorf("TotalPages = %d, want 3", out.Stats.TotalPages)
@@ -239,7 +195,7 @@ func TestGraphJSONNullFreedom(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	out := graphAnalysis(idx)
+	out := b.analyzeGraph(idx)
 
 	data, err := json.Marshal(out)
 	if err != nil {
@@ -0,0 +1,88 @@
+package bull
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+const migrateUsage = `
+migrate - migrate open tasks of previous daily pages to today's page
+
+Syntax:
+  % bull migrate [--days=N] [--move] [--dry_run]
+
+Open tasks (list items with an unchecked [ ] checkbox, or in another state
+of task_states which does not conclude the task, e.g. [/]) of daily journal
+pages before today are appended to the daily page of today, together with
+their subtasks. The original tasks are marked as migrated ([>]), or removed
+with --move.
+
+Examples:
+  % bull --content ~/keep migrate
+  % bull --content ~/keep migrate --days=7 --dry_run
+`
+
+func migrate(args []string) error {
+	fset := flag.NewFlagSet("migrate", flag.ExitOnError)
+	fset.Usage = usage(fset, migrateUsage)
+	days := fset.Int("days", 0, "only migrate tasks of the last N days (0 means all previous days)")
+	move := fset.Bool("move", false, "remove migrated tasks from their original page instead of marking them as migrated")
+	dryRun := fset.Bool("dry_run", false, "do not actually migrate tasks, only print them")
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	content, err := os.OpenRoot(*contentDir)
+	if err != nil {
+		return err
+	}
+
+	cs, err := loadContentSettings(content)
+	if err != nil {
+		return err
+	}
+
+	idxReady := make(chan struct{})
+	bull := &bullServer{
+		content:         content,
+		contentDir:      *contentDir,
+		contentSettings: cs,
+		contentChanged:  make(chan struct{}),
+		idxReady:        idxReady,
+	}
+	if err := bull.init(); err != nil {
+		return err
+	}
+
+	idx, err := bull.index()
+	if err != nil {
+		return err
+	}
+	bull.idx.Store(idx)
+	close(idxReady)
+
+	m, err := bull.planMigration(idx, time.Now(), *days)
+	if err != nil {
+		return err
+	}
+	for _, src := range m.sources {
+		for _, task := range src.tasks {
+			for i, line := range task.Lines {
+				fmt.Printf("%s:%d: %s\n", src.pg.FileName, task.Line+i, line)
+			}
+		}
+	}
+	if *dryRun {
+		log.Printf("[dry-run] would migrate %d open tasks of %d pages to %s", m.numTasks(), len(m.sources), m.today)
+		return nil
+	}
+	if err := bull.applyMigration(m, *move); err != nil {
+		return err
+	}
+	log.Printf("migrated %d open tasks of %d pages to %s", m.numTasks(), len(m.sources), m.today)
+	return nil
+}
@@ -8,7 +8,12 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/gokrazy/bull/internal/frontmatter"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
 )
 
 const mvUsage = `
@@ -20,13 +25,21 @@ Syntax:
 src and dest can be either file names (ending in .md)
 or page names (without an .md suffix).
 
+To rename a section (heading or ^block ID) of a page and update
+all [[page#section]] links, specify the section as fragment:
+
+  % bull mv <page>#<section> <page>#<new section>
+
 Examples:
   % bull mv simd Performance/SIMD
   % bull mv simd.md Performance/SIMD.md
+  % bull mv 'simd#Getting started' 'simd#Installation'
 `
 
-func (b *bullServer) replaceLinks(linker, oldpg, newpg string) error {
-	f, err := b.content.Open(linker)
+// rewriteFile replaces the content of fn (relative to the content
+// directory) with the result of calling rewrite on it.
+func (b *bullServer) rewriteFile(fn string, rewrite func([]byte) []byte) error {
+	f, err := b.content.Open(fn)
 	if err != nil {
 		return err
 	}
@@ -35,8 +48,8 @@ func (b *bullServer) replaceLinks(linker, oldpg, newpg string) error {
 	if err != nil {
 		return err
 	}
-	pb = replaceWikilinkTargets(pb, oldpg, newpg)
-	f, err = b.content.OpenFile(linker, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	pb = rewrite(pb)
+	f, err = b.content.OpenFile(fn, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
 	if err != nil {
 		return err
 	}
@@ -50,17 +63,17 @@ func (b *bullServer) replaceLinks(linker, oldpg, newpg string) error {
 	return nil
 }
 
-// replaceWikilinkTargets rewrites every [[oldpg…]] / ![[oldpg…]] in src to use
-// newpg as the target, preserving any fragment, label, and the GFM table-cell
-// pipe escape ('\|'). Forms handled:
-//
-//	[[oldpg]]
-//	[[oldpg|label]]
-//	[[oldpg\|label]]   (table-escaped form)
-//	[[oldpg#frag]]
-//	[[oldpg#frag|label]]
-//	![[oldpg…]]        (embed)
-func replaceWikilinkTargets(src []byte, oldpg, newpg string) []byte {
+func (b *bullServer) replaceLinks(linker, oldpg, newpg string) error {
+	match := b.linksTo(b.idx.Load(), file2page(linker), oldpg)
+	return b.rewriteFile(linker, func(pb []byte) []byte {
+		return replaceWikilinkTargetsFunc(pb, match, newpg)
+	})
+}
+
+// rewriteWikilinks calls rewrite with the inner content (the bytes between
+// "[[" and "]]") of every wikilink and embed in src, and replaces the inner
+// content with the result if rewrite returns true.
+func rewriteWikilinks(src []byte, rewrite func(inner []byte) ([]byte, bool)) []byte {
 	var out bytes.Buffer
 	out.Grow(len(src))
 	for i := 0; i < len(src); {
@@ -84,11 +97,9 @@ func replaceWikilinkTargets(src []byte, oldpg, newpg string) []byte {
 		}
 		contentEnd := contentStart + closeRel
 		inner := src[contentStart:contentEnd]
-		target, consumed := parseWikilinkTarget(inner)
-		if string(target) == oldpg {
+		if replaced, ok := rewrite(inner); ok {
 			out.Write(src[i:contentStart])
-			out.WriteString(newpg)
-			out.Write(inner[consumed:])
+			out.Write(replaced)
 			out.WriteString("]]")
 		} else {
 			out.Write(src[i : contentEnd+2])
@@ -98,6 +109,82 @@ func replaceWikilinkTargets(src []byte, oldpg, newpg string) []byte {
 	return out.Bytes()
 }
 
+// replaceWikilinkTargets rewrites every [[oldpg…]] / ![[oldpg…]] in src to use
+// newpg as the target, preserving any fragment, label, and the GFM table-cell
+// pipe escape ('\|'). Forms handled:
+//
+//	[[oldpg]]
+//	[[oldpg|label]]
+//	[[oldpg\|label]]   (table-escaped form)
+//	[[oldpg#frag]]
+//	[[oldpg#frag|label]]
+//	![[oldpg…]]        (embed)
+func replaceWikilinkTargets(src []byte, oldpg, newpg string) []byte {
+	return replaceWikilinkTargetsFunc(src, func(target string) bool {
+		return target == oldpg
+	}, newpg)
+}
+
+// replaceWikilinkTargetsFunc is like replaceWikilinkTargets, but rewrites
+// the wikilinks whose target matches, e.g. relative links (see linksTo).
+func replaceWikilinkTargetsFunc(src []byte, match func(target string) bool, newpg string) []byte {
+	return rewriteWikilinks(src, func(inner []byte) ([]byte, bool) {
+		target, consumed := parseWikilinkTarget(inner)
+		if len(target) == 0 || !match(string(target)) {
+			return nil, false
+		}
+		return append([]byte(newpg), inner[consumed:]...), true
+	})
+}
+
+// replaceWikilinkFragments rewrites every [[pg#oldfrag…]] / ![[pg#oldfrag…]]
+// in src to use newfrag as the fragment, preserving target and label. With
+// local set (src is the content of pg), [[#oldfrag…]] is rewritten, too.
+// Fragments which refer to the same heading as oldfrag match, e.g.
+// [[pg#Getting started]] and [[pg#getting-started]].
+func replaceWikilinkFragments(src []byte, pg, oldfrag, newfrag string, local bool) []byte {
+	return replaceWikilinkFragmentsFunc(src, func(target string) bool {
+		return target == pg
+	}, oldfrag, newfrag, local)
+}
+
+// replaceWikilinkFragmentsFunc is like replaceWikilinkFragments, but rewrites
+// the wikilinks whose target matches, e.g. relative links (see linksTo).
+func replaceWikilinkFragmentsFunc(src []byte, match func(target string) bool, oldfrag, newfrag string, local bool) []byte {
+	return rewriteWikilinks(src, func(inner []byte) ([]byte, bool) {
+		target, consumed := parseWikilinkTarget(inner)
+		if len(target) == 0 && !local ||
+			len(target) > 0 && !match(string(target)) {
+			return nil, false
+		}
+		fragment, ok := wikilinkFragment(inner[consumed:])
+		if !ok || !sameAnchor(string(fragment), oldfrag) {
+			return nil, false
+		}
+		var out []byte
+		out = append(out, inner[:consumed+1]...) // including #
+		out = append(out, newfrag...)
+		out = append(out, inner[consumed+1+len(fragment):]...)
+		return out, true
+	})
+}
+
+// wikilinkFragment returns the fragment at the start of rest, the remainder
+// of a wikilink's inner content after its target (see parseWikilinkTarget).
+func wikilinkFragment(rest []byte) ([]byte, bool) {
+	fragment, ok := bytes.CutPrefix(rest, []byte("#"))
+	if !ok {
+		return nil, false
+	}
+	if pipe := bytes.IndexByte(fragment, '|'); pipe >= 0 {
+		fragment = fragment[:pipe]
+		if len(fragment) > 1 && fragment[len(fragment)-1] == '\\' {
+			fragment = fragment[:len(fragment)-1]
+		}
+	}
+	return fragment, true
+}
+
 // parseWikilinkTarget returns the page-name portion of a wikilink's inner
 // content (the bytes between "[[" and "]]") and the number of bytes that
 // belong to it in the source. It mirrors the wikilink parser: split on the
@@ -119,6 +206,122 @@ func parseWikilinkTarget(inner []byte) (target []byte, consumed int) {
 	return beforePipe, len(beforePipe)
 }
 
+// renameSection renames the heading (or ^block ID) of pg which oldfrag
+// refers to and returns the updated content of pg (as stored on disk).
+func (b *bullServer) renameSection(pg *page, oldfrag, newfrag string) (string, bool) {
+	source := []byte(frontmatter.Blank(pg.DiskContent))
+	doc := b.converter(pg).Parser().Parse(text.NewReader(source))
+	start, stop := -1, -1
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Heading:
+			if n.Lines().Len() != 1 || strings.HasPrefix(oldfrag, "^") {
+				return ast.WalkSkipChildren, nil
+			}
+			line := n.Lines().At(0)
+			heading := strings.TrimRight(string(line.Value(source)), " \t")
+			id, _ := n.AttributeString("id")
+			idb, _ := id.([]byte)
+			if string(idb) == oldfrag || strings.EqualFold(heading, oldfrag) || sameAnchor(heading, oldfrag) {
+				start, stop = line.Start, line.Start+len(heading)
+				return ast.WalkStop, nil
+			}
+			return ast.WalkSkipChildren, nil
+
+		case *ast.Paragraph, *ast.TextBlock:
+			if blockID(n, source) != oldfrag {
+				return ast.WalkContinue, nil
+			}
+			last := n.Lines().At(n.Lines().Len() - 1)
+			start = last.Start + bytes.LastIndex(last.Value(source), []byte(oldfrag))
+			stop = start + len(oldfrag)
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	if start == -1 {
+		return pg.DiskContent, false
+	}
+	return pg.DiskContent[:start] + newfrag + pg.DiskContent[stop:], true
+}
+
+// mvSection renames the section oldfrag of page src to newfrag and updates
+// all links to the section. When the section was already renamed (e.g. in
+// the editor), only the links are updated.
+func (b *bullServer) mvSection(src, oldfrag, newfrag string, dryRun bool) error {
+	dryRunPrefix := ""
+	if dryRun {
+		dryRunPrefix = "[dry-run] "
+	}
+	if strings.HasPrefix(oldfrag, "^") != strings.HasPrefix(newfrag, "^") {
+		return fmt.Errorf("cannot rename %q to %q: block IDs (^id) can only be renamed to block IDs", oldfrag, newfrag)
+	}
+	possibilities := page2files(src)
+	if isMarkdown(src) {
+		possibilities = []string{src}
+	}
+	pg, err := b.readFirst(possibilities)
+	if err != nil {
+		return err
+	}
+	idx := b.idx.Load()
+	var anchors []string
+	if doc, ok := idx.text.docs[pg.PageName]; ok {
+		anchors = doc.anchors
+	}
+	content, renamed := b.renameSection(pg, oldfrag, newfrag)
+	switch {
+	case renamed:
+		log.Printf("%srename section %q → %q in %s", dryRunPrefix, oldfrag, newfrag, pg.FileName)
+	case hasAnchor(anchors, newfrag):
+		log.Printf("section %q already exists in %s, only updating links", newfrag, pg.FileName)
+	default:
+		return fmt.Errorf("page %s has no section %q", pg.PageName, oldfrag)
+	}
+	if !dryRun {
+		match := b.linksTo(idx, pg.PageName, pg.PageName)
+		content = string(replaceWikilinkFragmentsFunc([]byte(content), match, oldfrag, newfrag, true))
+		if err := b.rewriteFile(pg.FileName, func([]byte) []byte { return []byte(content) }); err != nil {
+			return err
+		}
+	}
+
+	names := append([]string{pg.PageName}, b.aliasesOf(idx, pg.PageName)...)
+	for _, linker := range b.backlinksTo(idx, pg.PageName) {
+		if linker == pg.PageName {
+			continue // already updated
+		}
+		linkerpg, err := b.readFirst(page2files(linker))
+		if err != nil {
+			log.Printf("  not found: %v", err)
+			continue
+		}
+
+		log.Printf(`%sreplace [[%s#%s]] → [[%s#%s]] in %s`, dryRunPrefix, pg.PageName, oldfrag, pg.PageName, newfrag, linkerpg.FileName)
+
+		if dryRun {
+			continue
+		}
+
+		matches := make([]func(string) bool, 0, len(names))
+		for _, name := range names {
+			matches = append(matches, b.linksTo(idx, linker, name))
+		}
+		if err := b.rewriteFile(linkerpg.FileName, func(pb []byte) []byte {
+			for _, match := range matches {
+				pb = replaceWikilinkFragmentsFunc(pb, match, oldfrag, newfrag, false)
+			}
+			return pb
+		}); err != nil {
+			log.Printf("  failed: %v", err)
+		}
+	}
+	return nil
+}
+
 func mv(args []string) error {
 	fset := flag.NewFlagSet("mv", flag.ExitOnError)
 	fset.Usage = usage(fset, mvUsage)
@@ -170,6 +373,16 @@ func mv(args []string) error {
 	log.Printf("discovered in %.2fs: directories: %d, pages: %d, links: %d", time.Since(start).Seconds(), idx.dirs, idx.pages, len(idx.backlinks))
 
 	src := fset.Arg(0)
+	if srcPage, oldfrag, ok := strings.Cut(src, "#"); ok {
+		destPage, newfrag, _ := strings.Cut(fset.Arg(1), "#")
+		if destPage != "" && destPage != srcPage {
+			return fmt.Errorf("moving sections to another page is not supported")
+		}
+		if oldfrag == "" || newfrag == "" {
+			return fmt.Errorf("syntax: mv <page>#<section> <page>#<new section>")
+		}
+		return bull.mvSection(srcPage, oldfrag, newfrag, *dryRun)
+	}
 	possibilities := page2files(src)
 	if isMarkdown(src) {
 		possibilities = []string{src}
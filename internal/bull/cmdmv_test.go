@@ -92,3 +92,107 @@ func TestReplaceWikilinkTargets(t *testing.T) {
 		})
 	}
 }
+
+func TestReplaceWikilinkFragments(t *testing.T) {
+	tests := []struct {
+		name             string
+		src              string
+		oldfrag, newfrag string
+		local            bool
+		want             string
+	}{
+		{
+			name:    "id",
+			src:     "see [[Foo#getting-started]] please",
+			oldfrag: "Getting started", newfrag: "Installation",
+			want: "see [[Foo#Installation]] please",
+		},
+		{
+			name:    "heading_text_with_label",
+			src:     "see [[Foo#Getting Started|the intro]] please",
+			oldfrag: "getting-started", newfrag: "Installation",
+			want: "see [[Foo#Installation|the intro]] please",
+		},
+		{
+			name:    "table_escaped",
+			src:     "| [[Foo#Getting started\\|intro]] |",
+			oldfrag: "Getting started", newfrag: "Installation",
+			want: "| [[Foo#Installation\\|intro]] |",
+		},
+		{
+			name:    "embed_and_block_id",
+			src:     "![[Foo#^step-1]] and [[Foo#step-1]]",
+			oldfrag: "^step-1", newfrag: "^first-step",
+			want: "![[Foo#^first-step]] and [[Foo#step-1]]",
+		},
+		{
+			name:    "other_page_and_section_left_alone",
+			src:     "[[Bar#getting-started]] [[Foo#usage]] [[Foo]]",
+			oldfrag: "getting-started", newfrag: "Installation",
+			want: "[[Bar#getting-started]] [[Foo#usage]] [[Foo]]",
+		},
+		{
+			name:    "local_only_when_requested",
+			src:     "[[#getting-started]]",
+			oldfrag: "getting-started", newfrag: "Installation",
+			want: "[[#getting-started]]",
+		},
+		{
+			name:    "local",
+			src:     "[[#getting-started]]",
+			oldfrag: "getting-started", newfrag: "Installation",
+			local: true,
+			want:  "[[#Installation]]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(replaceWikilinkFragments([]byte(tt.src), "Foo", tt.oldfrag, tt.newfrag, tt.local))
+			if got != tt.want {
+				t.Errorf("replaceWikilinkFragments(%q, Foo, %q, %q, %v):\n got: %q\nwant: %q",
+					tt.src, tt.oldfrag, tt.newfrag, tt.local, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMvSection(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"guide.md": "# Getting started ##\n\ninstall bull ^step-1\n\nsee [[#getting-started]]\n",
+		"index.md": "[[guide#Getting started]] and ![[guide#^step-1]]\n",
+		"other.md": "[[guide]] without section\n",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	read := func(fn string) string {
+		t.Helper()
+		pg, err := b.read(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pg.DiskContent
+	}
+
+	if err := b.mvSection("guide", "getting-started", "Installation", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.mvSection("guide", "^step-1", "^install", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.mvSection("guide", "nonexistent", "whatever", false); err == nil {
+		t.Errorf("mvSection(nonexistent) unexpectedly succeeded")
+	}
+	for fn, want := range map[string]string{
+		"guide.md": "# Installation ##\n\ninstall bull ^install\n\nsee [[#Installation]]\n",
+		"index.md": "[[guide#Installation]] and ![[guide#^install]]\n",
+		"other.md": "[[guide]] without section\n",
+	} {
+		if got := read(fn); got != want {
+			t.Errorf("%s after mvSection:\n got: %q\nwant: %q", fn, got, want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package bull
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gokrazy/bull/internal/query"
+)
+
+const searchUsage = `
+search - search pages from the command line
+
+Syntax:
+  % bull search [--output=text|json] <query>
+
+Matching lines are printed as page:line: text (like grep -n). Pages which
+match only by name or by filter (e.g. tag:) are printed as their name only.
+Like grep, bull search exits with status 1 if nothing matched and with
+status 2 on errors.
+
+Examples:
+  % bull --content ~/keep search milk
+  % bull --content ~/keep search '"new year" -path:days/'
+  % bull --content ~/keep search --output=json tag:project | jq .[].page_name
+`
+
+func search(args []string) error {
+	fset := flag.NewFlagSet("search", flag.ExitOnError)
+	fset.Usage = usage(fset, searchUsage)
+	output := fset.String("output", "text", "output format: text or json")
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() == 0 {
+		return fmt.Errorf("syntax: search [--output=text|json] <query>")
+	}
+	if *output != "text" && *output != "json" {
+		return fmt.Errorf("unknown output format %q (supported: text, json)", *output)
+	}
+	q, err := query.Parse(strings.Join(fset.Args(), " "))
+	if err != nil {
+		return err
+	}
+
+	content, err := os.OpenRoot(*contentDir)
+	if err != nil {
+		return err
+	}
+
+	cs, err := loadContentSettings(content)
+	if err != nil {
+		return err
+	}
+
+	idxReady := make(chan struct{})
+	close(idxReady)
+	bull := &bullServer{
+		content:         content,
+		contentDir:      *contentDir,
+		contentSettings: cs,
+		contentChanged:  make(chan struct{}),
+		idxReady:        idxReady,
+	}
+	if err := bull.init(); err != nil {
+		return err
+	}
+
+	idx, err := bull.index()
+	if err != nil {
+		return err
+	}
+	bull.idx.Store(idx)
+
+	results, err := bull.internalsearch(context.Background(), q, nil)
+	if err != nil {
+		return err
+	}
+	if err := writeSearchResults(os.Stdout, *output, results); err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return ErrNoMatches
+	}
+	return nil
+}
+
+// ErrNoMatches is returned by Runbull when the search verb found no page
+// matching the query. The bull command turns it into exit status 1 (like
+// grep) instead of an error message.
+var ErrNoMatches = errors.New("no pages matched")
+
+// writeSearchResults prints results in the specified output format.
+func writeSearchResults(w io.Writer, output string, results []match) error {
+	switch output {
+	case "json":
+		if results == nil {
+			results = make([]match, 0)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+
+	case "text":
+		for _, m := range results {
+			printed := false
+			for _, line := range m.Lines {
+				if line.Line == 0 {
+					continue // page name match
+				}
+				if _, err := fmt.Fprintf(w, "%s:%d: %s\n", m.PageName, line.Line, line.Text); err != nil {
+					return err
+				}
+				printed = true
+			}
+			if printed {
+				continue
+			}
+			// The page matched by name or by filter (e.g. tag:) only,
+			// so no line matched: print just the page name (like grep -l).
+			if _, err := fmt.Fprintf(w, "%s\n", m.PageName); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown output format %q (supported: text, json)", output)
+	}
+}
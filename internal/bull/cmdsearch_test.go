@@ -0,0 +1,78 @@
+package bull
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gokrazy/bull/internal/query"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSearchOutput(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"index.md":        "welcome\n\nbuy milk today",
+		"recipes/milk.md": "# rice pudding\n\nneeds rice",
+		"unrelated.md":    "nothing to see here",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	search := func(t *testing.T, raw string) []match {
+		t.Helper()
+		q, err := query.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := b.internalsearch(context.Background(), q, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return results
+	}
+
+	t.Run("Text", func(t *testing.T) {
+		var buf strings.Builder
+		if err := writeSearchResults(&buf, "text", search(t, "milk")); err != nil {
+			t.Fatal(err)
+		}
+		// recipes/milk matches by name only, so no line is printed.
+		want := "recipes/milk\n" +
+			"index:3: buy milk today\n"
+		if diff := cmp.Diff(want, buf.String()); diff != "" {
+			t.Errorf("unexpected output: diff (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var buf strings.Builder
+		if err := writeSearchResults(&buf, "json", search(t, "rice")); err != nil {
+			t.Fatal(err)
+		}
+		var got []match
+		if err := json.Unmarshal([]byte(buf.String()), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("got %d results, want 1", len(got))
+		}
+		want := []string{"# rice pudding", "needs rice"}
+		if diff := cmp.Diff(want, got[0].MatchingLines); diff != "" {
+			t.Errorf("unexpected matching lines: diff (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("NoResults", func(t *testing.T) {
+		var buf strings.Builder
+		if err := writeSearchResults(&buf, "json", search(t, "absent")); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := buf.String(), "[]\n"; got != want {
+			t.Errorf("unexpected output: got %q, want %q", got, want)
+		}
+	})
+}
@@ -66,6 +66,10 @@ func (c *Customization) serve(args []string) error {
 		"",
 		"whether pages should watch for updates and reload automatically. one of 'true', 'false' or 'workaround' (default 'true' or 'workaround' if available). the 'workaround' setting picks a random hostname like watchXYZ.localhost to work around the 6 connection limit applied when accessing bull via localhost (HTTP/1), which only works with systemd-resolved")
 
+	indexCache := fset.String("index_cache",
+		"",
+		"if non-empty, path to a directory in which bull persists its page index (links and search index), so that restarts only need to re-read modified pages. e.g. ~/.cache/bull")
+
 	if err := fset.Parse(args); err != nil {
 		return err
 	}
@@ -144,6 +148,7 @@ func (c *Customization) serve(args []string) error {
 		watch:           *watch,
 		contentChanged:  make(chan struct{}),
 		idxReady:        make(chan struct{}),
+		indexCacheDir:   *indexCache,
 	}
 	if err := bull.init(); err != nil {
 		return err
@@ -154,7 +159,7 @@ func (c *Customization) serve(args []string) error {
 	// idxReady before proceeding.
 	go func() {
 		start := time.Now()
-		log.Printf("indexing all pages (markdown files) in %s (for backlinks)", content.Name())
+		log.Printf("indexing all pages (markdown files) in %s (for backlinks and search)", content.Name())
 		result, err := bull.index()
 		if err != nil {
 			log.Printf("indexing failed: %v (backlinks will be unavailable)", err)
@@ -162,6 +167,7 @@ func (c *Customization) serve(args []string) error {
 			bull.idx.Store(&idx{
 				links:     make(map[string][]string),
 				backlinks: make(map[string][]string),
+				text:      newTextIndex(nil),
 			})
 		} else {
 			bull.idx.Store(result)
@@ -235,15 +241,26 @@ func (c *Customization) serve(args []string) error {
 		http.Handle(urlBullPrefix+"opensearch.xml", http.StripPrefix(urlBullPrefix, handleError(bull.opensearch)))
 	}
 	http.Handle("GET "+urlBullPrefix+"browse", handleError(bull.browse))
+	http.Handle("GET "+urlBullPrefix+"query/{name...}", handleError(bull.savedQuery))
+	http.Handle("GET "+urlBullPrefix+"tags", handleError(bull.tags))
+	http.Handle("GET "+urlBullPrefix+"tag/{tag...}", handleError(bull.tag))
+	http.Handle("GET "+urlBullPrefix+"health", handleError(bull.health))
+	http.Handle("GET "+urlBullPrefix+"graph/{page...}", handleError(bull.graphView))
+	http.Handle("GET "+urlBullPrefix+"mentions/{page...}", handleError(bull.mentions))
+	http.Handle("GET "+urlBullPrefix+"tasks", handleError(bull.tasksView))
+	http.Handle("GET "+urlBullPrefix+"today", handleError(bull.today))
+	http.Handle("POST "+urlBullPrefix+"_migrate", handleError(bull.migrateAPI))
 	http.Handle("GET "+urlBullPrefix+"buildinfo", handleError(bull.buildinfo))
 	http.Handle("GET "+urlBullPrefix+"watch/{page...}", handleError(bull.handleWatch))
 	http.Handle("POST "+urlBullPrefix+"save/{page...}", handleError(bull.save))
 	http.Handle("GET "+urlBullPrefix+"suggest", handleError(bull.suggest))
+	http.Handle("GET "+urlBullPrefix+"_pages", handleError(bull.pagesAPI))
 	http.Handle("GET "+urlBullPrefix+"search", handleError(bull.search))
 	http.Handle("GET "+urlBullPrefix+"_search", handleError(bull.searchAPI))
 	http.Handle("GET "+urlBullPrefix+"rename/{page...}", handleError(bull.rename))
 	http.Handle("POST "+urlBullPrefix+"_rename/{page...}", handleError(bull.renameAPI))
 	http.Handle("POST "+urlBullPrefix+"_itasklist/{page...}", handleError(bull.itasklistAPI))
+	http.Handle("POST "+urlBullPrefix+"_linkmention/{page...}", handleError(bull.linkMentionAPI))
 
 	ln, err := net.Listen("tcp", *listenAddr)
 	if err != nil {
@@ -21,12 +21,20 @@ func (b *bullServer) edit(w http.ResponseWriter, r *http.Request) error {
 			// It is not an error if a page does not exist,
 			// the edit handler can be used to create a page.
 			pageName := pageFromURL(r)
+			var content string // file does not exist
+			if tmpl := b.pageTemplate(pageName); tmpl != "" {
+				content, err = b.newPageContent(pageName, tmpl)
+				if err != nil {
+					return err
+				}
+			}
 			pg = &page{
-				Exists:   false,
-				FileName: page2desired(pageName),
-				PageName: pageName,
-				Content:  "",          // file does not exist
-				ModTime:  time.Time{}, // file does not exist
+				Exists:      false,
+				FileName:    page2desired(pageName),
+				PageName:    pageName,
+				Content:     content,
+				DiskContent: content,
+				ModTime:     time.Time{}, // file does not exist
 			}
 		} else {
 			return err
@@ -43,6 +51,7 @@ func (b *bullServer) edit(w http.ResponseWriter, r *http.Request) error {
 		MarkdownContent      string
 		StaticHash           func(string) string
 		StaticHashCodeMirror func() string
+		Journal              *journalNav
 	}{
 		URLPrefix:     b.root,
 		URLBullPrefix: b.URLBullPrefix(),
@@ -56,5 +65,6 @@ func (b *bullServer) edit(w http.ResponseWriter, r *http.Request) error {
 		StaticHashCodeMirror: func() string {
 			return hashSum(codemirror.BullCodemirror)
 		},
+		Journal: b.journalNav(pg),
 	})
 }
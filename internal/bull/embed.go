@@ -0,0 +1,238 @@
+package bull
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"html/template"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/gokrazy/bull/internal/frontmatter"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"go.abhg.dev/goldmark/wikilink"
+)
+
+// maxEmbedDepth limits how deeply embeds (![[page]]) can be nested.
+const maxEmbedDepth = 5
+
+// An embed is a transclusion of (a section of) another page,
+// written as ![[page]] or ![[page#section]] on a line of its own.
+type embed struct {
+	paragraph *ast.Paragraph // to be replaced with the embedded content
+	target    string         // page name
+	section   string         // heading ID or heading text, can be empty
+	anchor    string         // heading ID of section
+	pg        *page          // nil if err != nil
+	content   string         // content of pg, limited to section (if any)
+	err       error          // e.g. cycle or section not found
+}
+
+// key identifies the embedded content for cycle detection. A section of a
+// page can be embedded within the same page, but not within itself.
+func (e *embed) key() string {
+	if e.section == "" {
+		return e.target
+	}
+	return e.target + "#" + e.section
+}
+
+// embeds returns the embeds of doc, the parsed content of pg. stack
+// contains the embedding pages (starting with the page being rendered,
+// see embed.key) and is used to detect cycles.
+//
+// Embeds of pages which do not exist (e.g. images) are not returned,
+// they keep rendering as regular wiki links.
+func (b *bullServer) embeds(pg *page, doc ast.Node, stack []string) []*embed {
+	var embeds []*embed
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		wl, ok := n.(*wikilink.Node)
+		if !ok || !wl.Embed {
+			return ast.WalkContinue, nil
+		}
+		// Only embeds on a line of their own are transcluded:
+		// embedded content consists of block elements,
+		// which cannot be placed within a paragraph.
+		paragraph, ok := wl.Parent().(*ast.Paragraph)
+		if !ok || paragraph.ChildCount() != 1 {
+			return ast.WalkSkipChildren, nil
+		}
+		target := b.resolveTarget(b.idx.Load(), pg.PageName, file2page(string(wl.Target)))
+		if target == "" {
+			target = pg.PageName // ![[#section]] embeds from the same page
+		}
+		embedded, err := b.readFirst(page2files(target))
+		if err != nil {
+			return ast.WalkSkipChildren, nil // not a page (or missing)
+		}
+		e := &embed{
+			paragraph: paragraph,
+			target:    target,
+			section:   string(wl.Fragment),
+		}
+		embeds = append(embeds, e)
+		switch {
+		case slices.Contains(stack, e.key()):
+			e.err = fmt.Errorf("embed cycle: %s", strings.Join(append(stack, e.key()), " → "))
+			return ast.WalkSkipChildren, nil
+		case len(stack) > maxEmbedDepth:
+			e.err = fmt.Errorf("embeds nested too deeply (more than %d levels)", maxEmbedDepth)
+			return ast.WalkSkipChildren, nil
+		}
+		e.content = embedded.Content
+		if e.section != "" {
+			section, anchor, ok := b.section(embedded, e.section)
+			if !ok {
+				e.err = fmt.Errorf("section %q not found in page %s", e.section, target)
+				return ast.WalkSkipChildren, nil
+			}
+			e.content = section
+			e.anchor = anchor
+		}
+		e.pg = embedded
+		return ast.WalkSkipChildren, nil
+	})
+	return embeds
+}
+
+// section returns the content of pg from the heading identified by
+// section (its ID or text, case-insensitively) until the next heading
+// of the same or a higher level, and the ID of that heading.
+//
+// The lines before the heading are blanked (like the front matter in
+// parseMD), so that line numbers within the content match those of pg:
+// checkboxes of embedded task lists toggle tasks by line number.
+func (b *bullServer) section(pg *page, section string) (content, id string, ok bool) {
+	source := []byte(frontmatter.Blank(pg.Content))
+	doc := b.converter(pg).Parser().Parse(text.NewReader(source))
+	lineStart := func(n ast.Node) int {
+		start := n.Lines().At(0).Start
+		return bytes.LastIndexByte(source[:start], '\n') + 1
+	}
+	blanked := func(start, end int) string {
+		return strings.Repeat("\n", bytes.Count(source[:start], []byte{'\n'})) +
+			string(source[start:end])
+	}
+	start, level := -1, 0
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		h, ok := n.(*ast.Heading)
+		if !ok || h.Lines().Len() == 0 {
+			continue
+		}
+		if start > -1 {
+			if h.Level <= level {
+				return blanked(start, lineStart(h)), id, true
+			}
+			continue
+		}
+		attr, _ := h.AttributeString("id")
+		idb, _ := attr.([]byte)
+		if string(idb) == section ||
+			strings.EqualFold(string(h.Lines().Value(source)), section) {
+			start, level, id = lineStart(h), h.Level, string(idb)
+		}
+	}
+	if start == -1 {
+		return "", "", false
+	}
+	return blanked(start, len(source)), id, true
+}
+
+// transclude replaces the embeds within doc with their rendered content.
+func (b *bullServer) transclude(pg *page, doc ast.Node, stack []string) {
+	for _, e := range b.embeds(pg, doc, stack) {
+		n := &embedNode{embed: e}
+		if e.err == nil {
+			n.html = b.renderContent(e.pg, e.content, append(slices.Clip(stack), e.key()))
+		}
+		parent := e.paragraph.Parent()
+		parent.ReplaceChild(parent, e.paragraph, n)
+	}
+}
+
+// hashEmbeds writes the content of all (transitively) embedded pages of
+// doc to h, see contentHash.
+func (b *bullServer) hashEmbeds(h hash.Hash, pg *page, doc ast.Node, stack []string) {
+	for _, e := range b.embeds(pg, doc, stack) {
+		if e.err != nil {
+			h.Write([]byte(e.err.Error()))
+			continue
+		}
+		h.Write([]byte(e.content))
+		b.hashEmbeds(h, e.pg, b.parseMD(e.pg, e.content), append(slices.Clip(stack), e.key()))
+	}
+}
+
+// contentHash returns the hash of the content of pg, including the content
+// of all embedded pages, so that the watch stream can reload pages when an
+// embedded page changes.
+func (b *bullServer) contentHash(pg *page) string {
+	if !strings.Contains(pg.Content, "![[") {
+		return pg.ContentHash() // fast path: no embeds
+	}
+	h := quickhash()
+	h.Write([]byte(pg.Content))
+	b.hashEmbeds(h, pg, b.parseMD(pg, pg.Content), []string{pg.PageName})
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+var kindEmbed = ast.NewNodeKind("Embed")
+
+// embedNode is the (already rendered) content of an embed.
+type embedNode struct {
+	ast.BaseBlock
+
+	embed *embed
+	html  string
+}
+
+func (n *embedNode) Kind() ast.NodeKind { return kindEmbed }
+
+func (n *embedNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{
+		"Target":  n.embed.target,
+		"Section": n.embed.section,
+	}, nil)
+}
+
+type embedRenderer struct {
+	root string
+}
+
+func (r *embedRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindEmbed, r.render)
+}
+
+func (r *embedRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*embedNode)
+	e := n.embed
+	href := r.root + (&page{PageName: e.target}).URLPath()
+	label := e.target
+	if e.section != "" {
+		href += (&url.URL{Fragment: e.anchor}).String()
+		label += " › " + e.section
+	}
+	if e.err != nil {
+		fmt.Fprintf(w, "<div class=\"bull_embed bull_embed_error\"><a href=\"%s\">%s</a>: %s</div>\n",
+			href,
+			template.HTMLEscapeString(label),
+			template.HTMLEscapeString(e.err.Error()))
+		return ast.WalkSkipChildren, nil
+	}
+	fmt.Fprintf(w, "<div class=\"bull_embed\">\n<div class=\"bull_embed_source\"><a href=\"%s\">%s</a></div>\n",
+		href,
+		template.HTMLEscapeString(label))
+	w.WriteString(n.html)
+	w.WriteString("</div>\n")
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,164 @@
+package bull
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmbed(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"host.md": "# host\n\n" +
+			"![[shared]]\n\n" +
+			"![[recipes#Pancakes]]\n\n" +
+			"inline ![[shared]] stays a link\n\n" +
+			"![[img.png]]\n",
+		"shared.md": "shared *content*\n",
+		"recipes.md": "# recipes\n\n" +
+			"## Pancakes\n\nflour, eggs, milk\n\n" +
+			"### Variations\n\nblueberries\n\n" +
+			"## Waffles\n\nwaffle iron required\n",
+		"a.md":    "page a\n\n![[b]]\n",
+		"b.md":    "page b\n\n![[a]]\n",
+		"self.md": "# intro\n\n![[#outro]]\n\n# outro\n\nbye\n\n![[#outro]]\n",
+		"todo.md": "# todo\n\n## Home\n\n- [ ] laundry\n\n## Work\n\n- [ ] report\n\n" +
+			"## Today\n\n![[#Work]]\n",
+		"plan.md": "![[todo#Work]]\n",
+	})
+
+	render := func(pageName string) string {
+		t.Helper()
+		pg, err := b.readFirst(page2files(pageName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b.render(pg, pg.Content)
+	}
+
+	t.Run("PageAndSection", func(t *testing.T) {
+		got := render("host")
+		for _, want := range []string{
+			`<div class="bull_embed_source"><a href="/shared">shared</a></div>`,
+			`<p>shared <em>content</em></p>`,
+			`<a href="/recipes#pancakes">recipes › Pancakes</a>`,
+			`flour, eggs, milk`,
+			`blueberries`, // subsections are included
+			`inline <a href="/shared">shared</a> stays a link`,
+			`<img src="/img.png">`, // not a page
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("render(host) does not contain %q:\n%s", want, got)
+			}
+		}
+		if strings.Contains(got, "waffle") {
+			t.Errorf("render(host) unexpectedly contains the next section:\n%s", got)
+		}
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		got := render("a")
+		for _, want := range []string{
+			"page b",
+			"embed cycle: a → b → a",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("render(a) does not contain %q:\n%s", want, got)
+			}
+		}
+	})
+
+	t.Run("SameSection", func(t *testing.T) {
+		got := render("self")
+		// embedded in intro, in outro itself and in outro's embed of
+		// itself (whose nested embed of #outro is then a cycle)
+		if got, want := strings.Count(got, "<p>bye</p>"), 3; got != want {
+			t.Errorf("render(self) contains %q %d times, want %d", "<p>bye</p>", got, want)
+		}
+		if want := "embed cycle: self → self#outro → self#outro"; !strings.Contains(got, want) {
+			t.Errorf("render(self) does not contain %q:\n%s", want, got)
+		}
+	})
+
+	t.Run("MissingSection", func(t *testing.T) {
+		pg := &page{PageName: "missing", Content: "![[recipes#Soup]]\n"}
+		got := b.render(pg, pg.Content)
+		if want := `section &#34;Soup&#34; not found in page recipes`; !strings.Contains(got, want) {
+			t.Errorf("render does not contain %q:\n%s", want, got)
+		}
+	})
+
+	t.Run("Tasks", func(t *testing.T) {
+		b.editor = "textarea"
+		defer func() { b.editor = "" }()
+
+		// Checkboxes of embedded sections refer to the line of the task
+		// within the embedded page, not within the section.
+		const report = `<input type="checkbox" class="itask_open" title="open" data-state=" " data-line="9">report`
+		got := render("plan")
+		for _, want := range []string{
+			`<form class="itasklist" action="/_bull/_itasklist/todo" method="POST">`,
+			report,
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("render(plan) does not contain %q:\n%s", want, got)
+			}
+		}
+		got = render("todo")
+		if got, want := strings.Count(got, report), 2; got != want {
+			t.Errorf("render(todo) contains %q %d times, want %d", report, got, want)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("POST /_bull/_itasklist/{page...}", handleError(b.itasklistAPI))
+		testsrv := httptest.NewServer(mux)
+		defer testsrv.Close()
+		client := testsrv.Client()
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+		resp, err := client.PostForm(testsrv.URL+"/_bull/_itasklist/todo", url.Values{
+			"checkbox-line": {"9"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got, want := resp.StatusCode, http.StatusFound; got != want {
+			t.Fatalf("unexpected HTTP status: got %v, want %v", got, want)
+		}
+		pg, err := b.readFirst(page2files("todo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "# todo\n\n## Home\n\n- [ ] laundry\n\n## Work\n\n- [x] report\n\n" +
+			"## Today\n\n![[#Work]]\n"
+		if got := pg.DiskContent; got != want {
+			t.Errorf("after toggle: got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("ContentHash", func(t *testing.T) {
+		host, err := b.readFirst(page2files("host"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		before := b.contentHash(host)
+		if err := os.WriteFile(filepath.Join(b.contentDir, "shared.md"), []byte("changed"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if after := b.contentHash(host); after == before {
+			t.Errorf("contentHash did not change after modifying an embedded page")
+		}
+		shared, err := b.readFirst(page2files("shared"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := b.contentHash(shared), shared.ContentHash(); got != want {
+			t.Errorf("contentHash(shared) = %q, want ContentHash() = %q", got, want)
+		}
+	})
+}
@@ -8,7 +8,6 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"slices"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -162,7 +161,7 @@ func (b *bullServer) handleContentEvent(w *fsnotify.Watcher, event fsnotify.Even
 	case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
 		// Reading idx outside the lock is a benign TOCTOU: worst case we call
 		// removeFromIndex redundantly (it rechecks under the lock).
-		if old := b.idx.Load().links[pageName]; old != nil {
+		if _, ok := b.idx.Load().links[pageName]; ok {
 			b.removeFromIndex(pageName)
 		}
 		return true
@@ -173,16 +172,14 @@ func (b *bullServer) handleContentEvent(w *fsnotify.Watcher, event fsnotify.Even
 			log.Printf("fswatch: read %s: %v", rel, err)
 			return false
 		}
-		targets, err := b.linkTargets(pg)
+		refs, err := b.pageRefs(pg)
 		if err != nil {
-			log.Printf("fswatch: linkTargets %s: %v", rel, err)
+			log.Printf("fswatch: pageRefs %s: %v", rel, err)
 			return false
 		}
-		// Reading idx outside the lock is a benign TOCTOU: worst case we call
-		// updateIndex redundantly (it rechecks and stores an identical snapshot).
-		if !slices.Equal(b.idx.Load().links[pageName], targets) {
-			b.updateIndex(pageName, targets)
-		}
+		// The page content changed (even if its links did not),
+		// so the search index always needs an update.
+		b.indexPage(pg, refs)
 		return true
 	}
 	return false
@@ -199,7 +196,10 @@ func (b *bullServer) scanNewDir(w *fsnotify.Watcher, dir string) bool {
 	}
 
 	// Phase 1: walk and parse (no lock held).
-	var entries []indexEntry
+	var (
+		entries []indexEntry
+		docs    []*textDoc
+	)
 	if err := fs.WalkDir(b.content.FS(), dir, func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
 			log.Printf("fswatch: scanNewDir walk %s: %v", p, err)
@@ -224,12 +224,13 @@ func (b *bullServer) scanNewDir(w *fsnotify.Watcher, dir string) bool {
 			log.Printf("fswatch: scanNewDir read %s: %v", p, err)
 			return nil
 		}
-		targets, err := b.linkTargets(pg)
+		refs, err := b.pageRefs(pg)
 		if err != nil {
-			log.Printf("fswatch: scanNewDir linkTargets %s: %v", p, err)
+			log.Printf("fswatch: scanNewDir pageRefs %s: %v", p, err)
 			return nil
 		}
-		entries = append(entries, indexEntry{pageName: file2page(p), targets: targets})
+		entries = append(entries, indexEntry{pageName: file2page(p), targets: refs.targets})
+		docs = append(docs, newTextDoc(pg, refs))
 		return nil
 	}); err != nil {
 		log.Printf("fswatch: scanNewDir walk %s: %v", dir, err)
@@ -247,5 +248,11 @@ func (b *bullServer) scanNewDir(w *fsnotify.Watcher, dir string) bool {
 	b.idxMu.Lock()
 	defer b.idxMu.Unlock()
 	b.applyIndexBatchLocked(nil, updates)
+	b.updateTextIndexLocked(nil, docs)
+	added := make([]string, len(entries))
+	for idx, entry := range entries {
+		added[idx] = entry.pageName
+	}
+	b.reresolveLocked(added)
 	return true
 }
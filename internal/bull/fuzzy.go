@@ -0,0 +1,265 @@
+package bull
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Fuzzy page name matching parameters. Query characters must appear in the
+// page name in order (not necessarily adjacent), with bonuses for matches
+// that look like what the user meant to type. If the query is not a
+// subsequence of the page name, a few typos are tolerated instead.
+const (
+	fuzzyMatchScore      = 1  // per matched character
+	fuzzyConsecutive     = 4  // match directly follows the previous match
+	fuzzyBoundary        = 6  // match at the start of a word or path component
+	fuzzyBasenameStart   = 4  // match at the start of the last path component
+	fuzzyGapPenalty      = 1  // per skipped character between matches
+	fuzzyExactBonus      = 50 // page name (or its last component) equals the query
+	fuzzyDepthPenalty    = 2  // per directory level
+	fuzzyTypoPenalty     = 10 // per edit required for a typo-tolerant match
+	fuzzyDefaultLimit    = 20
+	fuzzyMaxLimit        = 100
+	fuzzyMinTypoQueryLen = 3 // shorter queries match too much when allowing typos
+)
+
+type fuzzyMatch struct {
+	PageName string `json:"page_name"`
+	Score    int    `json:"score"` // higher is better
+	// Positions are the indexes (in runes) of the page name characters that
+	// matched the query, for highlighting. Empty for typo-tolerant matches.
+	Positions []int `json:"positions"`
+
+	typos int
+	depth int
+}
+
+// maxTypos returns how many edits a query of n runes may need to match.
+func maxTypos(n int) int {
+	switch {
+	case n < fuzzyMinTypoQueryLen:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func isWordBoundary(prev, cur rune) bool {
+	switch prev {
+	case '/', ' ', '-', '_', '.':
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(cur)
+}
+
+// fuzzyScore matches query against name. Matching is case-insensitive.
+func fuzzyScore(query, name string) (fuzzyMatch, bool) {
+	q := []rune(strings.ToLower(query))
+	orig := []rune(name)
+	n := []rune(strings.ToLower(name))
+	if len(n) != len(orig) {
+		orig = n // lower-casing changed the number of runes
+	}
+	m := fuzzyMatch{
+		PageName: name,
+		depth:    strings.Count(name, "/"),
+	}
+	basenameStart := strings.LastIndexByte(name, '/') + 1
+	basenameStart = len([]rune(name[:basenameStart]))
+
+	if len(q) == 0 {
+		m.Score = -fuzzyDepthPenalty * m.depth
+		return m, true
+	}
+
+	if positions, score, ok := subsequenceScore(q, n, orig, basenameStart); ok {
+		m.Positions = positions
+		m.Score = score
+	} else {
+		typos := substringDistance(q, n)
+		if typos > maxTypos(len(q)) {
+			return fuzzyMatch{}, false
+		}
+		m.typos = typos
+		m.Score = len(q)*fuzzyMatchScore - typos*fuzzyTypoPenalty
+	}
+
+	lq := string(q)
+	if lq == string(n) || lq == string(n[basenameStart:]) {
+		m.Score += fuzzyExactBonus
+	}
+	m.Score -= fuzzyDepthPenalty * m.depth
+	return m, true
+}
+
+// subsequenceScore finds the best-scoring way to match q as a subsequence
+// of n (both lower-cased). orig is used to detect camelCase boundaries.
+func subsequenceScore(q, n, orig []rune, basenameStart int) (positions []int, score int, ok bool) {
+	// Quick rejection before the quadratic part.
+	i := 0
+	for _, r := range n {
+		if i < len(q) && r == q[i] {
+			i++
+		}
+	}
+	if i < len(q) {
+		return nil, 0, false
+	}
+
+	charScore := func(j int) int {
+		s := fuzzyMatchScore
+		if j == 0 || isWordBoundary(orig[j-1], orig[j]) {
+			s += fuzzyBoundary
+		}
+		if j == basenameStart {
+			s += fuzzyBasenameStart
+		}
+		return s
+	}
+
+	// best[i][j] is the best score for matching q[:i+1] with q[i] at n[j],
+	// prev[i][j] the position of q[i-1] in that match.
+	const unreachable = -1 << 30
+	best := make([][]int, len(q))
+	prev := make([][]int, len(q))
+	for i := range q {
+		best[i] = make([]int, len(n))
+		prev[i] = make([]int, len(n))
+		// With a linear gap penalty, the score of a gapped match via k is
+		// best[i-1][k] + fuzzyGapPenalty*k - fuzzyGapPenalty*(j-1), so it
+		// suffices to track the maximum of the first part for k <= j-2.
+		gapBest, gapPos := unreachable, -1
+		for j := range n {
+			best[i][j] = unreachable
+			if i > 0 && j >= 2 && best[i-1][j-2] > unreachable {
+				if v := best[i-1][j-2] + fuzzyGapPenalty*(j-2); v > gapBest {
+					gapBest, gapPos = v, j-2
+				}
+			}
+			if n[j] != q[i] {
+				continue
+			}
+			if i == 0 {
+				best[i][j] = charScore(j)
+				prev[i][j] = -1
+				continue
+			}
+			if j > 0 && best[i-1][j-1] > unreachable {
+				best[i][j] = best[i-1][j-1] + fuzzyConsecutive + charScore(j)
+				prev[i][j] = j - 1
+			}
+			if gapBest > unreachable {
+				if v := gapBest - fuzzyGapPenalty*(j-1) + charScore(j); v > best[i][j] {
+					best[i][j] = v
+					prev[i][j] = gapPos
+				}
+			}
+		}
+	}
+
+	last := len(q) - 1
+	end := -1
+	score = unreachable
+	for j := range n {
+		if best[last][j] > score {
+			score, end = best[last][j], j
+		}
+	}
+	if end == -1 {
+		return nil, 0, false
+	}
+	positions = make([]int, len(q))
+	for i, j := last, end; i >= 0; i-- {
+		positions[i] = j
+		j = prev[i][j]
+	}
+	return positions, score, true
+}
+
+// substringDistance returns the smallest number of edits (insertions,
+// deletions, substitutions and transpositions) needed to turn q into any
+// substring of n.
+func substringDistance(q, n []rune) int {
+	// Optimal string alignment distance with a free start and end in n
+	// (Sellers' algorithm), keeping the last three rows.
+	prev2 := make([]int, len(n)+1)
+	prev := make([]int, len(n)+1) // row 0: free start, all zeros
+	cur := make([]int, len(n)+1)
+	for i := 1; i <= len(q); i++ {
+		cur[0] = i
+		for j := 1; j <= len(n); j++ {
+			cost := 1
+			if q[i-1] == n[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && q[i-1] == n[j-2] && q[i-2] == n[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return slices.Min(prev)
+}
+
+// fuzzyPages returns up to limit page names matching query, best first.
+func fuzzyPages(names iter.Seq[string], query string, limit int) []fuzzyMatch {
+	var matches []fuzzyMatch
+	for name := range names {
+		if m, ok := fuzzyScore(query, name); ok {
+			matches = append(matches, m)
+		}
+	}
+	slices.SortFunc(matches, func(a, b fuzzyMatch) int {
+		if c := cmp.Compare(a.typos, b.typos); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.depth, b.depth); c != 0 {
+			return c
+		}
+		return strings.Compare(a.PageName, b.PageName)
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// pagesAPI serves fuzzy page name matches for the page switcher and for
+// wiki link completion in the editor.
+func (b *bullServer) pagesAPI(w http.ResponseWriter, r *http.Request) error {
+	limit := fuzzyDefaultLimit
+	if v := r.FormValue("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return httpError(http.StatusBadRequest, fmt.Errorf("invalid limit= parameter %q", v))
+		}
+		limit = min(limit, fuzzyMaxLimit)
+	}
+	<-b.idxReady
+	matches := fuzzyPages(maps.Keys(b.idx.Load().links), r.FormValue("q"), limit)
+	if matches == nil {
+		matches = make([]fuzzyMatch, 0)
+	}
+	for i := range matches {
+		if matches[i].Positions == nil {
+			matches[i].Positions = make([]int, 0)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(matches)
+}
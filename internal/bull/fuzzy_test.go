@@ -0,0 +1,138 @@
+package bull
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	for _, tt := range []struct {
+		query, name string
+		want        bool
+		positions   []int
+	}{
+		{query: "bull", name: "bull", want: true, positions: []int{0, 1, 2, 3}},
+		{query: "bl", name: "bull", want: true, positions: []int{0, 2}},
+		{query: "BULL", name: "projects/bull", want: true, positions: []int{9, 10, 11, 12}},
+		{query: "pb", name: "projects/bull", want: true, positions: []int{0, 9}},
+		{query: "nr", name: "notes/recipes", want: true, positions: []int{0, 6}},
+		{query: "buil", name: "bull", want: true},   // substitution
+		{query: "bluls", name: "bulls", want: true}, // transposition
+		{query: "recpies", name: "notes/recipes", want: true},
+		{query: "xyz", name: "bull", want: false},
+		{query: "lb", name: "bull", want: false}, // too short for typos
+	} {
+		t.Run(tt.query+"/"+tt.name, func(t *testing.T) {
+			m, ok := fuzzyScore(tt.query, tt.name)
+			if ok != tt.want {
+				t.Fatalf("fuzzyScore(%q, %q) = %v, want %v", tt.query, tt.name, ok, tt.want)
+			}
+			if tt.positions == nil {
+				return
+			}
+			if diff := cmp.Diff(tt.positions, m.Positions); diff != "" {
+				t.Errorf("fuzzyScore(%q, %q): unexpected positions: diff (-want +got):\n%s", tt.query, tt.name, diff)
+			}
+		})
+	}
+}
+
+func TestFuzzyPages(t *testing.T) {
+	names := []string{
+		"bull",
+		"bulletin",
+		"projects/bull",
+		"archive/2024/bull",
+		"boundless",
+		"bulk",
+		"notes/recipes",
+	}
+	for _, tt := range []struct {
+		query string
+		want  []string
+	}{
+		{
+			query: "bull",
+			want: []string{
+				"bull",              // exact
+				"projects/bull",     // exact basename, deeper
+				"archive/2024/bull", // exact basename, even deeper
+				"bulletin",          // prefix
+				"bulk",              // one typo
+			},
+		},
+		{
+			query: "pbull",
+			want: []string{
+				"projects/bull",     // subsequence
+				"bull",              // one typo
+				"bulletin",          // one typo
+				"archive/2024/bull", // one typo, deeper
+			},
+		},
+		{
+			query: "recipe",
+			want:  []string{"notes/recipes"},
+		},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			var got []string
+			for _, m := range fuzzyPages(slices.Values(names), tt.query, fuzzyDefaultLimit) {
+				got = append(got, m.PageName)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("fuzzyPages(%q): unexpected diff (-want +got):\n%s", tt.query, diff)
+			}
+		})
+	}
+
+	if got := fuzzyPages(slices.Values(names), "", 3); len(got) != 3 {
+		t.Errorf("fuzzyPages(\"\", 3) returned %d results, want 3", len(got))
+	}
+}
+
+func TestPagesAPI(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"index.md":         "hello",
+		"projects/bull.md": "a bullet journal",
+		"bulletin.md":      "news",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET "+b.URLBullPrefix()+"_pages", handleError(b.pagesAPI))
+	testsrv := httptest.NewServer(mux)
+	defer testsrv.Close()
+
+	resp, err := testsrv.Client().Get(testsrv.URL + "/_bull/_pages?q=bul&limit=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("unexpected HTTP status: got %v, want %v", got, want)
+	}
+	var got []fuzzyMatch
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := []fuzzyMatch{
+		{PageName: "bulletin", Positions: []int{0, 1, 2}},
+	}
+	if diff := cmp.Diff(want, got, cmpIgnoreScore); diff != "" {
+		t.Errorf("unexpected response: diff (-want +got):\n%s", diff)
+	}
+}
+
+var cmpIgnoreScore = cmp.Comparer(func(a, b fuzzyMatch) bool {
+	return a.PageName == b.PageName && slices.Equal(a.Positions, b.Positions)
+})
@@ -0,0 +1,259 @@
+package bull
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// linkGraph is the link graph between pages (or a part of it), for
+// exporting it in formats which other tools understand.
+type linkGraph struct {
+	root  string      // page whose neighborhood this is, or empty
+	nodes []string    // page names, sorted
+	edges [][2]string // source and target page names, sorted
+}
+
+// linkGraph returns the links between all pages of idx or, if root is not
+// empty, between the pages at most depth links away from root (following
+// links in both directions). Links to aliases are links to the canonical
+// page, links to missing pages are left out (see analyzeGraph).
+func (b *bullServer) linkGraph(idx *idx, root string, depth int) (*linkGraph, error) {
+	canonical := func(target string) (string, bool) {
+		if _, ok := idx.links[target]; ok {
+			return target, true
+		}
+		return b.resolveAlias(idx, target)
+	}
+	outgoing := func(pageName string) []string {
+		var targets []string
+		for _, target := range idx.links[pageName] {
+			if target, ok := canonical(target); ok && target != pageName {
+				targets = append(targets, target)
+			}
+		}
+		slices.Sort(targets)
+		return slices.Compact(targets)
+	}
+
+	nodes := make(map[string]bool)
+	if root == "" {
+		for pageName := range idx.links {
+			nodes[pageName] = true
+		}
+	} else {
+		pageName, ok := canonical(root)
+		if !ok {
+			return nil, fmt.Errorf("page %q not found", root)
+		}
+		root = pageName
+		nodes[root] = true
+		frontier := []string{root}
+		for range depth {
+			var next []string
+			for _, pageName := range frontier {
+				neighbors := append(outgoing(pageName), b.backlinksTo(idx, pageName)...)
+				for _, neighbor := range neighbors {
+					if !nodes[neighbor] {
+						nodes[neighbor] = true
+						next = append(next, neighbor)
+					}
+				}
+			}
+			frontier = next
+		}
+	}
+
+	g := &linkGraph{
+		root:  root,
+		nodes: slices.Sorted(maps.Keys(nodes)),
+	}
+	for _, source := range g.nodes {
+		for _, target := range outgoing(source) {
+			if nodes[target] {
+				g.edges = append(g.edges, [2]string{source, target})
+			}
+		}
+	}
+	return g, nil
+}
+
+// dotQuote returns s as a quoted DOT ID. Unlike Go strings, DOT strings only
+// know escaped quotes (and backslashes), everything else is used verbatim.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// writeDOT writes g in the Graphviz DOT language.
+func (g *linkGraph) writeDOT(w io.Writer) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "digraph bull {\n")
+	fmt.Fprintf(&buf, "  node [shape=box];\n")
+	for _, node := range g.nodes {
+		if node == g.root {
+			fmt.Fprintf(&buf, "  %s [style=bold];\n", dotQuote(node))
+			continue
+		}
+		fmt.Fprintf(&buf, "  %s;\n", dotQuote(node))
+	}
+	for _, edge := range g.edges {
+		fmt.Fprintf(&buf, "  %s -> %s;\n", dotQuote(edge[0]), dotQuote(edge[1]))
+	}
+	fmt.Fprintf(&buf, "}\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeGraphML writes g in the GraphML format (e.g. for Gephi or yEd).
+func (g *linkGraph) writeGraphML(w io.Writer) error {
+	type data struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+	type node struct {
+		ID   string `xml:"id,attr"`
+		Data data   `xml:"data"`
+	}
+	type edge struct {
+		Source string `xml:"source,attr"`
+		Target string `xml:"target,attr"`
+	}
+	type key struct {
+		ID       string `xml:"id,attr"`
+		For      string `xml:"for,attr"`
+		AttrName string `xml:"attr.name,attr"`
+		AttrType string `xml:"attr.type,attr"`
+	}
+	type graph struct {
+		ID          string `xml:"id,attr"`
+		EdgeDefault string `xml:"edgedefault,attr"`
+		Nodes       []node `xml:"node"`
+		Edges       []edge `xml:"edge"`
+	}
+	type graphML struct {
+		XMLName xml.Name `xml:"http://graphml.graphdrawing.org/xmlns graphml"`
+		Key     key      `xml:"key"`
+		Graph   graph    `xml:"graph"`
+	}
+	// Node IDs are page names, which are unique.
+	doc := graphML{
+		Key: key{ID: "label", For: "node", AttrName: "label", AttrType: "string"},
+		Graph: graph{
+			ID:          "bull",
+			EdgeDefault: "directed",
+		},
+	}
+	for _, pageName := range g.nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node{
+			ID:   pageName,
+			Data: data{Key: "label", Value: pageName},
+		})
+	}
+	for _, e := range g.edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, edge{Source: e[0], Target: e[1]})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeMermaid writes g as a Mermaid flowchart. If href is not nil, nodes
+// link to the URL it returns for their page name.
+func (g *linkGraph) writeMermaid(w io.Writer, href func(pageName string) string) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "flowchart LR\n")
+	ids := make(map[string]string, len(g.nodes))
+	for i, pageName := range g.nodes {
+		// Mermaid node IDs are restricted to a few characters,
+		// so page names are only used as labels.
+		ids[pageName] = "n" + strconv.Itoa(i)
+		label := strings.ReplaceAll(pageName, `"`, "#quot;")
+		fmt.Fprintf(&buf, "  %s[\"%s\"]\n", ids[pageName], label)
+	}
+	for _, edge := range g.edges {
+		fmt.Fprintf(&buf, "  %s --> %s\n", ids[edge[0]], ids[edge[1]])
+	}
+	if g.root != "" {
+		fmt.Fprintf(&buf, "  style %s stroke-width:3px\n", ids[g.root])
+	}
+	if href != nil {
+		for _, pageName := range g.nodes {
+			fmt.Fprintf(&buf, "  click %s href %s\n", ids[pageName], strconv.Quote(href(pageName)))
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// graphDepth returns the depth requested via the depth URL parameter.
+func graphDepth(r *http.Request) (int, error) {
+	depth := r.FormValue("depth")
+	if depth == "" {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(depth)
+	if err != nil || n < 0 {
+		return 0, httpError(http.StatusBadRequest, fmt.Errorf("invalid depth %q", depth))
+	}
+	return n, nil
+}
+
+func (b *bullServer) graphContent(pageName string, depth int) ([]byte, error) {
+	<-b.idxReady
+	idx := b.idx.Load()
+	g, err := b.linkGraph(idx, pageName, depth)
+	if err != nil {
+		return nil, httpError(http.StatusNotFound, err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# graph: [[%s]]\n\n", g.root)
+	fmt.Fprintf(&buf, "%s (at most %s away) and %s",
+		pluralize(len(g.nodes), "page", "pages"),
+		pluralize(depth, "link", "links"),
+		pluralize(len(g.edges), "link", "links"))
+	graphURL := b.URLBullPrefix() + "graph/" + (&url.URL{Path: g.root}).EscapedPath()
+	fmt.Fprintf(&buf, " — [more](%s?depth=%d)", graphURL, depth+1)
+	if depth > 0 {
+		fmt.Fprintf(&buf, " • [fewer](%s?depth=%d)", graphURL, depth-1)
+	}
+	fmt.Fprintf(&buf, "\n\n")
+	fmt.Fprintf(&buf, "```mermaid\n")
+	if err := g.writeMermaid(&buf, func(pageName string) string {
+		return b.root + (&url.URL{Path: pageName}).EscapedPath()
+	}); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&buf, "```\n")
+	return buf.Bytes(), nil
+}
+
+func (b *bullServer) graphView(w http.ResponseWriter, r *http.Request) error {
+	pageName := strings.Trim(r.PathValue("page"), "/")
+	if pageName == "" {
+		pageName = "index"
+	}
+	depth, err := graphDepth(r)
+	if err != nil {
+		return err
+	}
+	md, err := b.graphContent(pageName, depth)
+	if err != nil {
+		return err
+	}
+	return b.renderBullMarkdown(w, r, "graph/"+pageName, bytes.NewBuffer(md))
+}
@@ -0,0 +1,208 @@
+package bull
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newGraphExportTestBull(t *testing.T) *bullServer {
+	t.Helper()
+	b := newTestBull(t, map[string]string{
+		"index.md":         "[[projects]] and [[nowhere]]",
+		"projects.md":      "[[projects/bull]] and [[the wiki]]",
+		"projects/bull.md": "---\naliases: [the wiki]\n---\n[[design]] and [[projects]]",
+		"design.md":        `[["quoted" page]]`,
+		`"quoted" page.md`: "end",
+		"orphan.md":        "nobody links here",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+	return b
+}
+
+func TestLinkGraph(t *testing.T) {
+	b := newGraphExportTestBull(t)
+	idx := b.idx.Load()
+
+	t.Run("All", func(t *testing.T) {
+		g, err := b.linkGraph(idx, "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantNodes := []string{`"quoted" page`, "design", "index", "orphan", "projects", "projects/bull"}
+		if diff := cmp.Diff(wantNodes, g.nodes); diff != "" {
+			t.Errorf("nodes: unexpected diff (-want +got):\n%s", diff)
+		}
+		wantEdges := [][2]string{
+			{"design", `"quoted" page`},
+			{"index", "projects"},
+			{"projects", "projects/bull"},
+			{"projects/bull", "design"},
+			{"projects/bull", "projects"},
+		}
+		if diff := cmp.Diff(wantEdges, g.edges); diff != "" {
+			t.Errorf("edges: unexpected diff (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Neighborhood", func(t *testing.T) {
+		for _, tt := range []struct {
+			root      string
+			depth     int
+			wantNodes []string
+		}{
+			{"projects/bull", 0, []string{"projects/bull"}},
+			{"projects/bull", 1, []string{"design", "projects", "projects/bull"}},
+			{"the wiki", 2, []string{`"quoted" page`, "design", "index", "projects", "projects/bull"}},
+		} {
+			g, err := b.linkGraph(idx, tt.root, tt.depth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g.root != "projects/bull" {
+				t.Errorf("linkGraph(%q).root = %q, want projects/bull", tt.root, g.root)
+			}
+			if diff := cmp.Diff(tt.wantNodes, g.nodes); diff != "" {
+				t.Errorf("linkGraph(%q, %d): unexpected nodes: diff (-want +got):\n%s", tt.root, tt.depth, diff)
+			}
+		}
+		if _, err := b.linkGraph(idx, "nowhere", 1); err == nil {
+			t.Errorf("linkGraph(nowhere) unexpectedly succeeded")
+		}
+	})
+}
+
+func TestLinkGraphFormats(t *testing.T) {
+	b := newGraphExportTestBull(t)
+	g, err := b.linkGraph(b.idx.Load(), "design", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("DOT", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := g.writeDOT(&buf); err != nil {
+			t.Fatal(err)
+		}
+		want := `digraph bull {
+  node [shape=box];
+  "\"quoted\" page";
+  "design" [style=bold];
+  "projects/bull";
+  "design" -> "\"quoted\" page";
+  "projects/bull" -> "design";
+}
+`
+		if diff := cmp.Diff(want, buf.String()); diff != "" {
+			t.Errorf("writeDOT: unexpected diff (-want +got):\n%s", diff)
+		}
+		if got, want := dotQuote("C:\\notes\tÜbersicht"), `"C:\\notes`+"\t"+`Übersicht"`; got != want {
+			t.Errorf("dotQuote = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("GraphML", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := g.writeGraphML(&buf); err != nil {
+			t.Fatal(err)
+		}
+		var got struct {
+			Graph struct {
+				Nodes []struct {
+					ID string `xml:"id,attr"`
+				} `xml:"node"`
+				Edges []struct {
+					Source string `xml:"source,attr"`
+					Target string `xml:"target,attr"`
+				} `xml:"edge"`
+			} `xml:"graph"`
+		}
+		if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("writeGraphML output is not valid XML: %v\n%s", err, buf.String())
+		}
+		if len(got.Graph.Nodes) != 3 || len(got.Graph.Edges) != 2 {
+			t.Errorf("writeGraphML: got %d nodes and %d edges, want 3 and 2:\n%s", len(got.Graph.Nodes), len(got.Graph.Edges), buf.String())
+		}
+		if got, want := got.Graph.Nodes[0].ID, `"quoted" page`; got != want {
+			t.Errorf("first node ID = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Mermaid", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := g.writeMermaid(&buf, func(pageName string) string { return "/" + pageName }); err != nil {
+			t.Fatal(err)
+		}
+		want := `flowchart LR
+  n0["#quot;quoted#quot; page"]
+  n1["design"]
+  n2["projects/bull"]
+  n1 --> n0
+  n2 --> n1
+  style n1 stroke-width:3px
+  click n0 href "/\"quoted\" page"
+  click n1 href "/design"
+  click n2 href "/projects/bull"
+`
+		if diff := cmp.Diff(want, buf.String()); diff != "" {
+			t.Errorf("writeMermaid: unexpected diff (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestGraphView(t *testing.T) {
+	b := newGraphExportTestBull(t)
+	if err := b.init(); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /_bull/graph/{page...}", handleError(b.graphView))
+	testsrv := httptest.NewServer(mux)
+	defer testsrv.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := testsrv.Client().Get(testsrv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := get("/_bull/graph/projects/bull?depth=2")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected HTTP status: got %v, want %v", status, http.StatusOK)
+	}
+	for _, want := range []string{
+		`<code class="language-mermaid">flowchart LR`,
+		`click n4 href &quot;/projects/bull&quot;`,
+		`5 pages (at most 2 links away) and 5 links`,
+		`<a href="/_bull/graph/projects/bull?depth=3">more</a>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("graph page does not contain %q:\n%s", want, body)
+		}
+	}
+
+	if status, _ := get("/_bull/graph/nowhere"); status != http.StatusNotFound {
+		t.Errorf("graph of missing page: got HTTP status %v, want %v", status, http.StatusNotFound)
+	}
+	if status, _ := get("/_bull/graph/index?depth=-1"); status != http.StatusBadRequest {
+		t.Errorf("graph with negative depth: got HTTP status %v, want %v", status, http.StatusBadRequest)
+	}
+}
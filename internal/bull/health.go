@@ -0,0 +1,138 @@
+package bull
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// emptyPages returns the (sorted) names of pages without content
+// (apart from front matter and whitespace).
+func emptyPages(idx *idx) []string {
+	if idx.text == nil {
+		return nil
+	}
+	var empty []string
+	for pageName, doc := range idx.text.docs {
+		if doc.empty {
+			empty = append(empty, pageName)
+		}
+	}
+	slices.Sort(empty)
+	return empty
+}
+
+// caseDuplicates returns groups of page names which differ only in case,
+// e.g. Projects and projects (which clash on case-insensitive file systems).
+func caseDuplicates(idx *idx) [][]string {
+	byFolded := make(map[string][]string)
+	for pageName := range idx.links {
+		folded := strings.ToLower(pageName)
+		byFolded[folded] = append(byFolded[folded], pageName)
+	}
+	var duplicates [][]string
+	for _, folded := range slices.Sorted(maps.Keys(byFolded)) {
+		if group := byFolded[folded]; len(group) > 1 {
+			slices.Sort(group)
+			duplicates = append(duplicates, group)
+		}
+	}
+	return duplicates
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+// tableCell escapes s for use in a GFM table cell, where | separates cells.
+func tableCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// tableCellLink returns a link to pageName for use in a GFM table cell. Page
+// names containing | cannot be wiki link targets (| separates the label), so
+// such pages are linked with a regular markdown link instead.
+func (b *bullServer) tableCellLink(pageName string) string {
+	if !strings.Contains(pageName, "|") {
+		return "[[" + pageName + "]]"
+	}
+	return "[" + tableCell(pageName) + "](" + b.root + (&page{PageName: pageName}).URLPath() + ")"
+}
+
+func (b *bullServer) healthContent() []byte {
+	<-b.idxReady
+	idx := b.idx.Load()
+	graph := b.analyzeGraph(idx)
+	empty := emptyPages(idx)
+	duplicates := caseDuplicates(idx)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# health\n\n")
+	fmt.Fprintf(&buf, "%s: %s, %s, %s, %s\n\n",
+		pluralize(len(idx.links), "page", "pages"),
+		pluralize(len(graph.BrokenLinks), "broken link", "broken links"),
+		pluralize(len(graph.Orphans), "orphan page", "orphan pages"),
+		pluralize(len(empty), "empty page", "empty pages"),
+		pluralize(len(duplicates), "duplicate page name", "duplicate page names"))
+
+	fmt.Fprintf(&buf, "## broken links\n\n")
+	if len(graph.BrokenLinks) == 0 {
+		fmt.Fprintf(&buf, "All links point to existing pages and sections.\n\n")
+	} else {
+		fmt.Fprintf(&buf, "| page | link target | missing section |\n")
+		fmt.Fprintf(&buf, "|------|-------------|-----------------|\n")
+		for _, bl := range graph.BrokenLinks {
+			fmt.Fprintf(&buf, "| %s | %s | %s |\n",
+				b.tableCellLink(bl.Source),
+				b.tableCellLink(bl.Target),
+				tableCell(bl.Fragment))
+		}
+		fmt.Fprintf(&buf, "\n")
+	}
+
+	fmt.Fprintf(&buf, "## orphan pages\n\n")
+	if len(graph.Orphans) == 0 {
+		fmt.Fprintf(&buf, "All pages (except index) are linked from another page.\n\n")
+	} else {
+		fmt.Fprintf(&buf, "No other page links to these pages:\n\n")
+		for _, orphan := range graph.Orphans {
+			fmt.Fprintf(&buf, "* [[%s]]\n", orphan)
+		}
+		fmt.Fprintf(&buf, "\n")
+	}
+
+	fmt.Fprintf(&buf, "## empty pages\n\n")
+	if len(empty) == 0 {
+		fmt.Fprintf(&buf, "No page is empty.\n\n")
+	} else {
+		for _, pageName := range empty {
+			fmt.Fprintf(&buf, "* [[%s]]\n", pageName)
+		}
+		fmt.Fprintf(&buf, "\n")
+	}
+
+	fmt.Fprintf(&buf, "## duplicate page names\n\n")
+	if len(duplicates) == 0 {
+		fmt.Fprintf(&buf, "No page names differ only in case.\n")
+	} else {
+		fmt.Fprintf(&buf, "These page names differ only in case:\n\n")
+		for _, group := range duplicates {
+			var links []string
+			for _, pageName := range group {
+				links = append(links, "[["+pageName+"]]")
+			}
+			fmt.Fprintf(&buf, "* %s\n", strings.Join(links, ", "))
+		}
+	}
+	return buf.Bytes()
+}
+
+func (b *bullServer) health(w http.ResponseWriter, r *http.Request) error {
+	return b.renderBullMarkdown(w, r, "health", bytes.NewBuffer(b.healthContent()))
+}
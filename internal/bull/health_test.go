@@ -0,0 +1,45 @@
+package bull
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHealth(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"index.md":    "see [[guide#setup]], [[nowhere]] and [[Projects]]",
+		"guide.md":    "# Getting started\n",
+		"Projects.md": "all projects",
+		"projects.md": "---\ntitle: empty\n---\n\n",
+		"a|b.md":      "[setup](guide#set|up), [x](x|y)",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	if diff := cmp.Diff([]string{"projects"}, emptyPages(idx)); diff != "" {
+		t.Errorf("emptyPages: unexpected diff (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([][]string{{"Projects", "projects"}}, caseDuplicates(idx)); diff != "" {
+		t.Errorf("caseDuplicates: unexpected diff (-want +got):\n%s", diff)
+	}
+
+	content := string(b.healthContent())
+	for _, want := range []string{
+		"5 pages: 4 broken links, 2 orphan pages, 1 empty page, 1 duplicate page name\n",
+		"| [a\\|b](/a%7Cb) | [[guide]] | set\\|up |\n",
+		"| [a\\|b](/a%7Cb) | [x\\|y](/x%7Cy) |  |\n",
+		"| [[index]] | [[guide]] | setup |\n",
+		"| [[index]] | [[nowhere]] |  |\n",
+		"## orphan pages\n\nNo other page links to these pages:\n\n* [[a|b]]\n* [[projects]]\n",
+		"* [[Projects]], [[projects]]\n",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("healthContent() does not contain %q:\n%s", want, content)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package bull
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gokrazy/bull/internal/frontmatter"
+	"github.com/google/renameio/v2"
+)
+
+// indexCacheVersion must be incremented whenever the meaning of the cached
+// data changes (e.g. pageRefs returns different targets).
+const indexCacheVersion = 8
+
+// indexCacheEntry is the cached result of reading and indexing one page.
+type indexCacheEntry struct {
+	FileName  string
+	ModTime   time.Time
+	Targets   []string
+	Tags      []string
+	Contexts  map[string][]string
+	Fragments map[string][]string
+	Anchors   []string
+	Headings  []heading
+	Wikilinks []string
+
+	// The remaining fields are those of textDoc: like the index in memory,
+	// the cache does not contain the content of pages.
+	Meta    *frontmatter.FrontMatter
+	Empty   bool
+	Tokens  []string
+	Length  int
+	HasTask bool
+	Tasks   []indexCacheTask
+}
+
+// indexCacheTask is the cached form of a task.
+type indexCacheTask struct {
+	Line  int
+	State byte
+	Text  string
+	Due   time.Time
+}
+
+// valid returns whether the entry can be used instead of reading pg,
+// which was discovered by an indexer with readModTime set.
+func (e indexCacheEntry) valid(pg *page) bool {
+	return e.FileName == pg.FileName && e.ModTime.Equal(pg.ModTime)
+}
+
+func (e indexCacheEntry) refs() *pageRefs {
+	return &pageRefs{
+		targets:   e.Targets,
+		tags:      e.Tags,
+		contexts:  e.Contexts,
+		fragments: e.Fragments,
+		anchors:   e.Anchors,
+		headings:  e.Headings,
+		wikilinks: e.Wikilinks,
+	}
+}
+
+// doc returns the textDoc of the cached page.
+func (e indexCacheEntry) doc() *textDoc {
+	doc := newTextDoc(&page{
+		PageName: file2page(e.FileName),
+		FileName: e.FileName,
+		ModTime:  e.ModTime,
+		Meta:     e.Meta,
+	}, e.refs())
+	doc.empty = e.Empty
+	doc.tokens = e.Tokens
+	doc.length = e.Length
+	doc.hasTask = e.HasTask
+	for _, t := range e.Tasks {
+		doc.tasks = append(doc.tasks, task{
+			pageName: doc.pageName,
+			line:     t.Line,
+			state:    t.State,
+			text:     t.Text,
+			due:      t.Due,
+		})
+	}
+	return doc
+}
+
+type indexCacheFile struct {
+	Version  int
+	Settings string // content settings in effect when the cache was written
+	Entries  map[string]indexCacheEntry
+}
+
+func (b *bullServer) indexCachePath() string {
+	if b.indexCacheDir == "" {
+		return "" // cache disabled
+	}
+	abs, err := filepath.Abs(b.contentDir)
+	if err != nil {
+		abs = b.contentDir
+	}
+	return filepath.Join(b.indexCacheDir, "index-"+hashSum([]byte(abs))+".gob")
+}
+
+func (b *bullServer) indexCacheSettings() string {
+	return fmt.Sprintf("%+v", b.contentSettings)
+}
+
+// loadIndexCache returns the entries of the on-disk index cache, or nil if
+// the cache is disabled, does not exist (yet) or cannot be used.
+func (b *bullServer) loadIndexCache() map[string]indexCacheEntry {
+	fn := b.indexCachePath()
+	if fn == "" {
+		return nil
+	}
+	f, err := os.Open(fn)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("index cache: %v", err)
+		}
+		// Return an empty (non-nil) map so that the indexer
+		// collects modification times for saveIndexCache.
+		return make(map[string]indexCacheEntry)
+	}
+	defer f.Close()
+	var cf indexCacheFile
+	if err := gob.NewDecoder(f).Decode(&cf); err != nil {
+		log.Printf("index cache: decoding %s: %v", fn, err)
+		return make(map[string]indexCacheEntry)
+	}
+	if cf.Version != indexCacheVersion || cf.Settings != b.indexCacheSettings() {
+		log.Printf("index cache: %s is outdated, ignoring", fn)
+		return make(map[string]indexCacheEntry)
+	}
+	log.Printf("index cache: loaded %d entries from %s", len(cf.Entries), fn)
+	return cf.Entries
+}
+
+// saveIndexCache writes the on-disk index cache (if enabled). Errors are only
+// logged: the cache is an optimization, bull works fine without it.
+func (b *bullServer) saveIndexCache(links map[string][]string, docs map[string]*textDoc) {
+	fn := b.indexCachePath()
+	if fn == "" {
+		return
+	}
+	cf := indexCacheFile{
+		Version:  indexCacheVersion,
+		Settings: b.indexCacheSettings(),
+		Entries:  make(map[string]indexCacheEntry, len(docs)),
+	}
+	for pageName, doc := range docs {
+		entry := indexCacheEntry{
+			FileName:  doc.fileName,
+			ModTime:   doc.modTime,
+			Targets:   links[pageName],
+			Tags:      doc.tags,
+			Contexts:  doc.contexts,
+			Fragments: doc.fragments,
+			Anchors:   doc.anchors,
+			Headings:  doc.headings,
+			Wikilinks: doc.wikilinks,
+			Meta:      doc.meta,
+			Empty:     doc.empty,
+			Tokens:    doc.tokens,
+			Length:    doc.length,
+			HasTask:   doc.hasTask,
+		}
+		for _, t := range doc.tasks {
+			entry.Tasks = append(entry.Tasks, indexCacheTask{
+				Line:  t.line,
+				State: t.state,
+				Text:  t.text,
+				Due:   t.due,
+			})
+		}
+		cf.Entries[pageName] = entry
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&cf); err != nil {
+		log.Printf("index cache: encoding: %v", err)
+		return
+	}
+	if err := os.MkdirAll(b.indexCacheDir, 0755); err != nil {
+		log.Printf("index cache: %v", err)
+		return
+	}
+	if err := renameio.WriteFile(fn, buf.Bytes(), 0644); err != nil {
+		log.Printf("index cache: %v", err)
+		return
+	}
+}
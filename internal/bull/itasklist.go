@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"os"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/gokrazy/bull/internal/itasklist"
 )
 
 func (b *bullServer) itasklistAPI(w http.ResponseWriter, r *http.Request) error {
@@ -33,31 +35,54 @@ func (b *bullServer) itasklistAPI(w http.ResponseWriter, r *http.Request) error
 		return err
 	}
 
-	updatedContent := toggleCheckbox(pg.DiskContent, int(line))
-	if updatedContent != pg.DiskContent {
-		f, err := b.content.OpenFile(pg.FileName, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-		if _, err := f.Write([]byte(updatedContent)); err != nil {
-			return err
+	if numLines := strings.Count(pg.DiskContent, "\n") + 1; line < 1 || line > int64(numLines) {
+		return httpError(http.StatusBadRequest, fmt.Errorf("checkbox-line %d out of range [1, %d]", line, numLines))
+	}
+
+	target := b.root + pg.URLPath()
+	if ret := r.FormValue("return"); ret != "" {
+		if !b.validReturnURL(ret) {
+			return httpError(http.StatusBadRequest, fmt.Errorf("invalid return URL %q", ret))
 		}
-		if err := f.Close(); err != nil {
+		target = ret
+	}
+
+	updatedContent := toggleCheckbox(pg.DiskContent, int(line), b.contentSettings.TaskStates)
+	if updatedContent != pg.DiskContent {
+		// writePage updates the index, so that the task dashboard
+		// reflects the new state when returning to it.
+		if err := b.writePage(pg.PageName, updatedContent); err != nil {
 			return err
 		}
 	}
 
-	http.Redirect(w, r, b.root+pg.URLPath(), http.StatusFound)
+	http.Redirect(w, r, target, http.StatusFound)
 	return nil
 }
 
+// validReturnURL returns whether ret is a generated page of this bull
+// instance (e.g. /_bull/tasks), which the _itasklist API may redirect to.
+// Anything else, in particular //host or /\host (which browsers treat like
+// //host), would make the API an open redirect.
+func (b *bullServer) validReturnURL(ret string) bool {
+	if strings.Contains(ret, `\`) || strings.HasPrefix(ret, "//") {
+		return false
+	}
+	u, err := url.Parse(ret)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Opaque != "" {
+		return false
+	}
+	return strings.HasPrefix(u.Path, b.URLBullPrefix())
+}
+
 // like the regexp in itasklist.go, but not anchored
-var taskListRegexp = regexp.MustCompile(`\[([\sxX])\]\s*`)
+var taskListRegexp = regexp.MustCompile(`\[([\sxX/><-])\]\s*`)
 
-func toggleCheckbox(content string, checkboxLine int) string {
+// toggleCheckbox advances the checkbox in line checkboxLine (1-based) of
+// content to the next state in the cycle states (see itasklist.NextState).
+func toggleCheckbox(content string, checkboxLine int, states []string) string {
 	lines := strings.Split(content, "\n")
-	if checkboxLine-1 > len(lines)-1 {
+	if checkboxLine < 1 || checkboxLine > len(lines) {
 		return content // checkbox line out of range
 	}
 	line := lines[checkboxLine-1]
@@ -65,15 +90,24 @@ func toggleCheckbox(content string, checkboxLine int) string {
 	if m == nil {
 		return content // line contains no checkbox
 	}
-	before := line[:m[2]]
-	after := line[m[3]:]
-	checked := line[m[2]:m[3]]
-	opposite := "x"
-	if checked == "x" || checked == "X" {
-		opposite = " "
-	}
-	lines[checkboxLine-1] = before + opposite + after
+	next := itasklist.NextState(states, line[m[2]:m[3]])
 	// TODO: move the checkbox into the section it belongs to
 	// (ticked / un-ticked parts of the list)
+	return setCheckbox(content, checkboxLine, next)
+}
+
+// setCheckbox sets the state of the checkbox in line checkboxLine (1-based)
+// of content, e.g. to > for migrated tasks (see migrateTasks).
+func setCheckbox(content string, checkboxLine int, state string) string {
+	lines := strings.Split(content, "\n")
+	if checkboxLine < 1 || checkboxLine > len(lines) {
+		return content // checkbox line out of range
+	}
+	line := lines[checkboxLine-1]
+	m := taskListRegexp.FindStringSubmatchIndex(line)
+	if m == nil {
+		return content // line contains no checkbox
+	}
+	lines[checkboxLine-1] = line[:m[2]] + state + line[m[3]:]
 	return strings.Join(lines, "\n")
 }
@@ -1,6 +1,8 @@
 package bull
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -11,7 +13,7 @@ func TestToggleCheckbox(t *testing.T) {
 - [ ] bar
 - [ ] baz
 `
-	got := toggleCheckbox(content, 2)
+	got := toggleCheckbox(content, 2, nil)
 	want := `- [ ] foo
 - [x] bar
 - [ ] baz
@@ -19,4 +21,77 @@ func TestToggleCheckbox(t *testing.T) {
 	if diff := cmp.Diff(want, got); diff != "" {
 		t.Errorf("toggleCheckbox: unexpected diff (-want +got):\n%s", diff)
 	}
+
+	// Out of range lines leave the content unchanged.
+	for _, line := range []int{-1, 0, 5} {
+		if got := toggleCheckbox(content, line, nil); got != content {
+			t.Errorf("toggleCheckbox(line=%d) = %q, want unchanged %q", line, got, content)
+		}
+	}
+}
+
+func TestToggleCheckboxStates(t *testing.T) {
+	states := []string{" ", "/", "x", "-"}
+	for _, tt := range []struct {
+		line string
+		want string
+	}{
+		{"- [ ] task", "- [/] task"},
+		{"- [/] task", "- [x] task"},
+		{"- [X] task", "- [-] task"},
+		{"- [-] task", "- [ ] task"},
+		// states which are not part of the cycle re-open the task
+		{"- [>] task", "- [ ] task"},
+		{"- [<] task", "- [ ] task"},
+		{"- [?] task", "- [?] task"},
+	} {
+		if got := toggleCheckbox(tt.line, 1, states); got != tt.want {
+			t.Errorf("toggleCheckbox(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestRenderTaskStates(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"tasks.md": "- [ ] open\n- [x] done\n- [/] in progress\n- [>] migrated\n- [<] scheduled\n- [-] cancelled\n- [?] no task\n",
+	})
+	render := func() string {
+		t.Helper()
+		pg, err := b.read("tasks.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := b.converter(pg).Renderer().Render(&buf, []byte(pg.Content), b.parseMD(pg, pg.Content)); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	b.editor = "textarea"
+	got := render()
+	for _, want := range []string{
+		`<form class="itasklist" action="/_bull/_itasklist/tasks" method="POST">`,
+		`<input type="checkbox" class="itask_open" title="open" data-state=" " data-line="1">open`,
+		`<input checked="" type="checkbox" class="itask_done" title="done" data-state="x" data-line="2">done`,
+		`<input type="checkbox" class="itask_inprogress" title="inprogress" data-state="/" data-line="3">in progress`,
+		`<input type="checkbox" class="itask_migrated" title="migrated" data-state="&gt;" data-line="4">migrated`,
+		`<input type="checkbox" class="itask_scheduled" title="scheduled" data-state="&lt;" data-line="5">scheduled`,
+		`<input type="checkbox" class="itask_cancelled" title="cancelled" data-state="-" data-line="6">cancelled`,
+		`<li>[?] no task</li>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("rendered HTML does not contain %q:\n%s", want, got)
+		}
+	}
+
+	// In read-only mode, task lists are rendered with disabled checkboxes.
+	b.editor = ""
+	got = render()
+	if strings.Contains(got, "<form") {
+		t.Errorf("read-only rendered HTML contains a form:\n%s", got)
+	}
+	if want := `<input type="checkbox" disabled="" class="itask_migrated" title="migrated" data-state="&gt;" data-line="4">`; !strings.Contains(got, want) {
+		t.Errorf("read-only rendered HTML does not contain %q:\n%s", want, got)
+	}
 }
@@ -0,0 +1,156 @@
+package bull
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// journalDate returns the day of the daily journal page pageName
+// (see ContentSettings.DailyPage).
+func (b *bullServer) journalDate(pageName string) (time.Time, bool) {
+	layout := b.contentSettings.DailyPage
+	if layout == "" {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(layout, pageName, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// journalPage returns the name of the daily journal page for the day of t.
+func (b *bullServer) journalPage(t time.Time) string {
+	return t.Format(b.contentSettings.DailyPage)
+}
+
+type journalLink struct {
+	PageName string
+	URL      string
+}
+
+// journalNav is the daily journal navigation displayed in the navbar.
+type journalNav struct {
+	TodayURL string
+	// IsToday is set on the daily page of the current day.
+	IsToday bool
+	// Prev and Next are the closest daily pages before and after the
+	// daily page which is displayed, nil if there are none (or if the
+	// displayed page is not a daily page).
+	Prev, Next *journalLink
+}
+
+// journalNav returns the navigation for pg, or nil if there is no journal.
+func (b *bullServer) journalNav(pg *page) *journalNav {
+	if b.contentSettings.DailyPage == "" {
+		return nil
+	}
+	nav := &journalNav{
+		TodayURL: b.URLBullPrefix() + "today",
+	}
+	day, ok := b.journalDate(pg.PageName)
+	if !ok {
+		return nav
+	}
+	nav.IsToday = pg.PageName == b.journalPage(time.Now())
+	select {
+	case <-b.idxReady:
+	default:
+		return nav // the index is not ready yet
+	}
+	days := b.journalDays(b.idx.Load())
+	// days[i:j] are the daily pages of day (usually just pg).
+	i, _ := slices.BinarySearchFunc(days, day, func(d journalDay, t time.Time) int {
+		return d.day.Compare(t)
+	})
+	j := i
+	for j < len(days) && days[j].day.Equal(day) {
+		j++
+	}
+	if i > 0 {
+		nav.Prev = b.journalLink(days[i-1].pageName)
+	}
+	if j < len(days) {
+		nav.Next = b.journalLink(days[j].pageName)
+	}
+	return nav
+}
+
+// journalDay is a daily journal page and its day.
+type journalDay struct {
+	pageName string
+	day      time.Time
+}
+
+// lazyJournalDays builds the daily journal pages of an idx on first use.
+type lazyJournalDays struct {
+	once sync.Once
+	days []journalDay
+}
+
+// journalDays returns the daily journal pages of idx, sorted by day
+// (and page name).
+func (b *bullServer) journalDays(idx *idx) []journalDay {
+	build := func() []journalDay {
+		var days []journalDay
+		for pageName := range idx.links {
+			if t, ok := b.journalDate(pageName); ok {
+				days = append(days, journalDay{pageName: pageName, day: t})
+			}
+		}
+		slices.SortFunc(days, func(x, y journalDay) int {
+			return cmp.Or(x.day.Compare(y.day), strings.Compare(x.pageName, y.pageName))
+		})
+		return days
+	}
+	if idx.days == nil {
+		return build()
+	}
+	idx.days.once.Do(func() {
+		idx.days.days = build()
+	})
+	return idx.days.days
+}
+
+func (b *bullServer) journalLink(pageName string) *journalLink {
+	return &journalLink{
+		PageName: pageName,
+		URL:      b.root + (&url.URL{Path: pageName}).EscapedPath(),
+	}
+}
+
+// today redirects to the daily journal page of the current day, which it
+// creates from ContentSettings.DailyTemplate (or the template for new pages
+// in its directory, see pageTemplate) unless it exists.
+func (b *bullServer) today(w http.ResponseWriter, r *http.Request) error {
+	if b.contentSettings.DailyPage == "" {
+		return httpError(http.StatusNotFound, fmt.Errorf("no daily journal configured (daily_page in _bull/content-settings.toml is empty)"))
+	}
+	pageName := b.journalPage(time.Now())
+	tmpl := cmp.Or(b.contentSettings.DailyTemplate, b.pageTemplate(pageName))
+	if tmpl != "" && b.editor != "" {
+		_, err := b.readFirst(page2files(pageName))
+		if os.IsNotExist(err) {
+			content, err := b.newPageContent(pageName, tmpl)
+			if err != nil {
+				return err
+			}
+			log.Printf("creating daily page %s from template %s", pageName, tmpl)
+			if err := b.writePage(pageName, content); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+	http.Redirect(w, r, b.journalLink(pageName).URL, http.StatusFound)
+	return nil
+}
@@ -0,0 +1,168 @@
+package bull
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJournalNav(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"days/2026-01-30.md": "a",
+		"days/2026-02-01.md": "b",
+		"days/2026-02-05.md": "c",
+		"days/notes.md":      "not a daily page",
+		"index.md":           "hello",
+	})
+	// The daily journal is opt-in.
+	if got := b.journalNav(&page{PageName: "days/2026-02-01"}); got != nil {
+		t.Errorf("journalNav without content settings = %+v, want nil", got)
+	}
+	b.contentSettings.DailyPage = "days/2006-01-02"
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	for _, tt := range []struct {
+		pageName string
+		want     *journalNav
+	}{
+		{
+			pageName: "index",
+			want:     &journalNav{TodayURL: "/_bull/today"},
+		},
+		{
+			pageName: "days/2026-02-01",
+			want: &journalNav{
+				TodayURL: "/_bull/today",
+				Prev:     &journalLink{PageName: "days/2026-01-30", URL: "/days/2026-01-30"},
+				Next:     &journalLink{PageName: "days/2026-02-05", URL: "/days/2026-02-05"},
+			},
+		},
+		{
+			// Pages which do not exist (yet) link to their neighbors, too.
+			pageName: "days/2026-02-03",
+			want: &journalNav{
+				TodayURL: "/_bull/today",
+				Prev:     &journalLink{PageName: "days/2026-02-01", URL: "/days/2026-02-01"},
+				Next:     &journalLink{PageName: "days/2026-02-05", URL: "/days/2026-02-05"},
+			},
+		},
+		{
+			pageName: "days/2026-01-30",
+			want: &journalNav{
+				TodayURL: "/_bull/today",
+				Next:     &journalLink{PageName: "days/2026-02-01", URL: "/days/2026-02-01"},
+			},
+		},
+	} {
+		got := b.journalNav(&page{PageName: tt.pageName})
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("journalNav(%s): unexpected diff (-want +got):\n%s", tt.pageName, diff)
+		}
+	}
+
+	today := b.journalNav(&page{PageName: b.journalPage(time.Now())})
+	if !today.IsToday {
+		t.Errorf("journalNav(today).IsToday = false, want true")
+	}
+
+	b.contentSettings.DailyPage = ""
+	if got := b.journalNav(&page{PageName: "days/2026-02-01"}); got != nil {
+		t.Errorf("journalNav without daily_page = %+v, want nil", got)
+	}
+}
+
+func TestToday(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"templates/day.md": "# plan\n\n- [ ] \n",
+	})
+	b.contentSettings.DailyPage = "days/2006-01-02"
+	if err := b.init(); err != nil {
+		t.Fatal(err)
+	}
+	b.contentSettings.DailyTemplate = "templates/day"
+	mux := http.NewServeMux()
+	mux.Handle("GET /_bull/today", handleError(b.today))
+	mux.Handle("GET /{page...}", handleError(b.handleRender))
+	testsrv := httptest.NewServer(mux)
+	defer testsrv.Close()
+	client := testsrv.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	todayPage := b.journalPage(time.Now())
+
+	get := func(path string) *http.Response {
+		t.Helper()
+		resp, err := client.Get(testsrv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// Read-only: redirect, but do not create the page.
+	resp := get("/_bull/today")
+	resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusFound; got != want {
+		t.Fatalf("unexpected HTTP status: got %v, want %v", got, want)
+	}
+	if got, want := resp.Header.Get("Location"), "/"+todayPage; got != want {
+		t.Errorf("unexpected redirect: got %q, want %q", got, want)
+	}
+	if _, err := b.readFirst(page2files(todayPage)); err == nil {
+		t.Errorf("/_bull/today created %s in read-only mode", todayPage)
+	}
+
+	b.editor = "textarea"
+	resp = get("/_bull/today")
+	resp.Body.Close()
+	pg, err := b.readFirst(page2files(todayPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pg.DiskContent, "# plan\n\n- [ ] \n"; got != want {
+		t.Errorf("today's page: got %q, want %q", got, want)
+	}
+
+	// Existing pages are not overwritten.
+	if err := b.writePage(todayPage, "written"); err != nil {
+		t.Fatal(err)
+	}
+	resp = get("/_bull/today")
+	resp.Body.Close()
+	pg, err = b.readFirst(page2files(todayPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pg.DiskContent, "written"; got != want {
+		t.Errorf("today's page after second visit: got %q, want %q", got, want)
+	}
+
+	t.Run("Nav", func(t *testing.T) {
+		resp := get("/" + todayPage)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(body), `<a id="bull_nav_today" href="/_bull/today"`) {
+			t.Errorf("page does not contain a link to today:\n%s", body)
+		}
+	})
+
+	b.contentSettings.DailyPage = ""
+	resp = get("/_bull/today")
+	resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusNotFound; got != want {
+		t.Errorf("without daily_page: got HTTP status %v, want %v", got, want)
+	}
+}
@@ -1,6 +1,7 @@
 package bull
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io/fs"
@@ -10,10 +11,14 @@ import (
 	"path"
 	"runtime"
 	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"github.com/gokrazy/bull/internal/frontmatter"
+	"github.com/gokrazy/bull/internal/hashtag"
 	"github.com/yuin/goldmark/ast"
 	"go.abhg.dev/goldmark/wikilink"
 	"golang.org/x/sync/errgroup"
@@ -26,27 +31,180 @@ type idx struct {
 	// backlinks maps from page name (e.g. index) to
 	// page names that contain a link to that page (e.g. SETTINGS, projects, …).
 	backlinks map[string][]string
+	// text is the full-text search index over the content of all pages.
+	text *textIndex
+	// names is built on first use, see (*idx).resolver.
+	names *lazyLinkResolver
+	// days is built on first use, see journalDays.
+	days *lazyJournalDays
 }
 
-func (b *bullServer) linkTargets(pg *page) ([]string, error) {
-	var targets []string
+// pageRefs are the references from a page to other pages and to tags.
+type pageRefs struct {
+	targets []string // link targets, sorted and deduplicated
+	tags    []string // case-folded hashtags (without #), sorted and deduplicated
+	// contexts maps link targets to the (deduplicated) text of the
+	// paragraphs, list items or headings containing the links,
+	// in document order. Shown with the backlinks of the target.
+	contexts map[string][]string
+	// fragments maps link targets to the (sorted, deduplicated) fragments
+	// of links to them, e.g. [[bull#install]] results in bull: [install].
+	// Links within the page ([[#install]]) use the page name as target.
+	fragments map[string][]string
+	// anchors are the heading IDs and ^block IDs of the page,
+	// sorted and deduplicated.
+	anchors []string
+	// headings are the headings of the page in document order.
+	headings []heading
+	// wikilinks are the wiki link targets as written (which might be
+	// relative, see resolveRefs), sorted and deduplicated.
+	wikilinks []string
+	// unresolved are the references as written if resolveRefs resolved
+	// any wiki link targets, nil otherwise.
+	unresolved *pageRefs
+}
+
+// heading is a heading of a page, as rendered.
+type heading struct {
+	Line int // 1-based
+	// ID is the ID that parser.WithAutoHeadingID generates
+	// when rendering the page.
+	ID string
+}
+
+// maxLinkContextLen limits the length (in bytes) of link contexts.
+const maxLinkContextLen = 300
+
+// linkContext returns the text of the block (paragraph, list item,
+// heading) containing the inline node n.
+func linkContext(n ast.Node, source []byte) string {
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		if p.Type() != ast.TypeBlock || p.Lines().Len() == 0 {
+			continue
+		}
+		lines := p.Lines()
+		parts := make([]string, 0, lines.Len())
+		for i := range lines.Len() {
+			line := lines.At(i)
+			parts = append(parts, strings.TrimSpace(string(line.Value(source))))
+		}
+		snippet := strings.Join(parts, " ")
+		// Render embeds as links: the context must not transclude pages.
+		snippet = strings.ReplaceAll(snippet, "![[", "[[")
+		if len(snippet) > maxLinkContextLen {
+			cut := maxLinkContextLen
+			for cut > 0 && !utf8.RuneStart(snippet[cut]) {
+				cut--
+			}
+			snippet = snippet[:cut] + "…"
+		}
+		return snippet
+	}
+	return ""
+}
+
+// pageRefs returns the references of pg, with wiki link targets resolved
+// against the current index (see resolveRefs).
+func (b *bullServer) pageRefs(pg *page) (*pageRefs, error) {
+	refs, err := b.parsePageRefs(pg)
+	if err != nil {
+		return nil, err
+	}
+	return b.resolveRefs(b.idx.Load(), pg.PageName, refs), nil
+}
+
+// parsePageRefs returns the references of pg, with wiki link targets as
+// written.
+func (b *bullServer) parsePageRefs(pg *page) (*pageRefs, error) {
+	var targets, tags, anchors, wikilinks []string
+	var headings []heading
+	contexts := make(map[string][]string)
+	fragments := make(map[string][]string)
+	source := []byte(frontmatter.Blank(pg.Content))
+	addLink := func(target, fragment string, n ast.Node) {
+		if fragment != "" {
+			key := target
+			if key == "" {
+				key = pg.PageName
+			}
+			fragments[key] = append(fragments[key], fragment)
+		}
+		if target == "" {
+			return
+		}
+		targets = append(targets, target)
+		snippet := linkContext(n, source)
+		if snippet == "" || slices.Contains(contexts[target], snippet) {
+			return
+		}
+		contexts[target] = append(contexts[target], snippet)
+	}
 
 	doc := b.parseMD(pg, pg.Content)
 	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if !entering {
 			return ast.WalkContinue, nil
 		}
+		switch n := n.(type) {
+		case *ast.Heading:
+			if id, ok := n.AttributeString("id"); ok {
+				if idb, ok := id.([]byte); ok {
+					anchors = append(anchors, string(idb))
+					if n.Lines().Len() > 0 {
+						start := n.Lines().At(0).Start
+						headings = append(headings, heading{
+							Line: 1 + bytes.Count(source[:start], []byte{'\n'}),
+							ID:   string(idb),
+						})
+					}
+				}
+			}
+		case *ast.Paragraph, *ast.TextBlock:
+			if id := blockID(n, source); id != "" {
+				anchors = append(anchors, id)
+			}
+		}
 		if wl, ok := n.(*wikilink.Node); ok {
-			targets = append(targets, string(wl.Target))
+			if len(wl.Target) > 0 {
+				wikilinks = append(wikilinks, string(wl.Target))
+			}
+			addLink(string(wl.Target), string(wl.Fragment), n)
 		}
 		if link, ok := n.(*ast.Link); ok {
-			targets = append(targets, string(link.Destination))
+			target, fragment := string(link.Destination), ""
+			if !strings.Contains(target, "://") {
+				target, fragment, _ = strings.Cut(target, "#")
+			}
+			addLink(target, fragment, n)
 		}
 		return ast.WalkContinue, nil
 	})
+	written := hashtag.Extract(doc)
+	if pg.Meta != nil {
+		written = append(written, pg.Meta.Tags...)
+	}
+	for _, tag := range written {
+		tags = append(tags, strings.ToLower(strings.TrimRight(tag, "/")))
+	}
+
+	for target, frags := range fragments {
+		slices.Sort(frags)
+		fragments[target] = slices.Compact(frags)
+	}
 
 	slices.Sort(targets)
-	return slices.Compact(targets), nil
+	slices.Sort(tags)
+	slices.Sort(anchors)
+	slices.Sort(wikilinks)
+	return &pageRefs{
+		targets:   slices.Compact(targets),
+		tags:      slices.Clip(slices.Compact(tags)),
+		contexts:  contexts,
+		fragments: fragments,
+		anchors:   slices.Compact(anchors),
+		headings:  headings,
+		wikilinks: slices.Compact(wikilinks),
+	}, nil
 }
 
 type indexer struct {
@@ -170,29 +328,43 @@ func (i *indexer) walk() error {
 func (b *bullServer) index() (*idx, error) {
 	i := newIndexer(b.content)
 
+	cache := b.loadIndexCache()
+	if cache != nil {
+		i.readModTime = true // required to validate cache entries
+	}
+
 	var (
 		linksMu sync.Mutex
 		links   = make(map[string][]string)
+		docs    = make(map[string]*textDoc)
 		readg   errgroup.Group
 	)
 	for range runtime.NumCPU() {
 		readg.Go(func() error {
 			linksN := make(map[string][]string)
+			docsN := make(map[string]*textDoc)
 			for pg := range i.readq {
+				if entry, ok := cache[pg.PageName]; ok && entry.valid(&pg) {
+					linksN[pg.PageName] = entry.Targets
+					docsN[pg.PageName] = entry.doc()
+					continue
+				}
 				// fmt.Printf("reading %s\n", fn)
 				pg, err := b.read(pg.FileName)
 				if err != nil {
 					return err
 				}
-				targets, err := b.linkTargets(pg)
+				refs, err := b.parsePageRefs(pg)
 				if err != nil {
 					return err
 				}
-				linksN[pg.PageName] = targets
+				linksN[pg.PageName] = refs.targets
+				docsN[pg.PageName] = newTextDoc(pg, refs)
 			}
 			linksMu.Lock()
 			defer linksMu.Unlock()
 			maps.Copy(links, linksN)
+			maps.Copy(docs, docsN)
 			return nil
 		})
 	}
@@ -202,11 +374,35 @@ func (b *bullServer) index() (*idx, error) {
 	if err := readg.Wait(); err != nil {
 		return nil, err
 	}
+	// The cache contains wiki link targets as written: how they resolve
+	// depends on which other pages exist.
+	b.saveIndexCache(links, docs)
+	if b.contentSettings.RelativeLinks {
+		unresolved := &idx{links: links, names: new(lazyLinkResolver)}
+		resolved := make(map[string][]string, len(links))
+		for pageName, doc := range docs {
+			refs := b.resolveRefs(unresolved, pageName, &pageRefs{
+				targets:   links[pageName],
+				contexts:  doc.contexts,
+				fragments: doc.fragments,
+				wikilinks: doc.wikilinks,
+			})
+			resolved[pageName] = refs.targets
+			doc.contexts = refs.contexts
+			doc.fragments = refs.fragments
+			doc.wikilinks = refs.wikilinks
+			doc.unresolved = refs.unresolved
+		}
+		links = resolved
+	}
 	return &idx{
 		dirs:      i.dirs.Load(),
 		pages:     i.pages.Load(),
 		links:     links,
 		backlinks: invertLinks(links),
+		text:      newTextIndex(docs),
+		names:     new(lazyLinkResolver),
+		days:      new(lazyJournalDays),
 	}, nil
 }
 
@@ -233,6 +429,7 @@ func (b *bullServer) removeFromIndex(pageName string) {
 	b.idxMu.Lock()
 	defer b.idxMu.Unlock()
 	b.removeFromIndexLocked(pageName)
+	b.reresolveLocked([]string{pageName})
 }
 
 // indexUpdate pairs a page name with its new link targets for batch operations.
@@ -272,11 +469,19 @@ func (b *bullServer) applyIndexBatchLocked(removals []string, updates []indexUpd
 		patchBacklinks(newBacklinks, u.pageName, added, removed)
 	}
 
+	text := old.text
+	if text != nil && len(removals) > 0 {
+		text = text.apply(removals, nil)
+	}
+
 	b.idx.Store(&idx{
 		dirs:      old.dirs,
 		pages:     uint64(len(newLinks)),
 		links:     newLinks,
 		backlinks: newBacklinks,
+		text:      text,
+		names:     new(lazyLinkResolver),
+		days:      new(lazyJournalDays),
 	})
 }
 
@@ -301,11 +506,19 @@ func (b *bullServer) removeFromIndexLocked(pageName string) {
 	newBacklinks := maps.Clone(old.backlinks)
 	patchBacklinks(newBacklinks, pageName, added, removed)
 
+	text := old.text
+	if text != nil {
+		text = text.apply([]string{pageName}, nil)
+	}
+
 	b.idx.Store(&idx{
 		dirs:      old.dirs,
 		pages:     uint64(len(newLinks)),
 		links:     newLinks,
 		backlinks: newBacklinks,
+		text:      text,
+		names:     new(lazyLinkResolver),
+		days:      new(lazyJournalDays),
 	})
 }
 
@@ -337,6 +550,9 @@ func (b *bullServer) updateIndexLocked(pageName string, newTargets []string) {
 		pages:     uint64(len(newLinks)),
 		links:     newLinks,
 		backlinks: newBacklinks,
+		text:      old.text,
+		names:     new(lazyLinkResolver),
+		days:      new(lazyJournalDays),
 	})
 }
 
@@ -83,8 +83,8 @@ func TestIndexPerformance(t *testing.T) {
 		t.Logf("%v per call (%d pages)", time.Since(start)/N, len(idx.links))
 	})
 
-	// 4. linkTargets (parse markdown + walk AST)
-	t.Run("linkTargets", func(t *testing.T) {
+	// 4. pageRefs (parse markdown + walk AST)
+	t.Run("pageRefs", func(t *testing.T) {
 		pg, err := b.read(somePage + ".md")
 		if err != nil {
 			for _, ext := range []string{".md", ".markdown"} {
@@ -97,10 +97,10 @@ func TestIndexPerformance(t *testing.T) {
 		if err != nil {
 			t.Skipf("could not read page %q: %v", somePage, err)
 		}
-		b.linkTargets(pg) // warm up
+		b.pageRefs(pg) // warm up
 		start := time.Now()
 		for range N {
-			b.linkTargets(pg)
+			b.pageRefs(pg)
 		}
 		t.Logf("%v per call (page %q, %d bytes)", time.Since(start)/N, somePage, len(pg.Content))
 	})
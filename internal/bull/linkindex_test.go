@@ -1,8 +1,12 @@
 package bull
 
 import (
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 
@@ -143,11 +147,11 @@ func TestUpdateIndex(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	targets, err := b.linkTargets(pg)
+	refs, err := b.pageRefs(pg)
 	if err != nil {
 		t.Fatal(err)
 	}
-	b.updateIndex(pg.PageName, targets)
+	b.updateIndex(pg.PageName, refs.targets)
 
 	cur := b.idx.Load()
 	if got := cur.backlinks["beta"]; len(got) != 0 {
@@ -184,11 +188,11 @@ func TestUpdateIndexPageCount(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	targets, err := b.linkTargets(pg)
+	refs, err := b.pageRefs(pg)
 	if err != nil {
 		t.Fatal(err)
 	}
-	b.updateIndex(pg.PageName, targets)
+	b.updateIndex(pg.PageName, refs.targets)
 
 	if got, want := b.idx.Load().pages, uint64(3); got != want {
 		t.Errorf("after add: pages = %d, want %d", got, want)
@@ -352,3 +356,122 @@ func TestConcurrentIndex(t *testing.T) {
 		t.Errorf("pages = %d, len(links) = %d", got, want)
 	}
 }
+
+func TestBacklinkContexts(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"bull.md": "---\naliases: [the wiki]\n---\nbull is a wiki\n",
+		"notes.md": strings.Join([]string{
+			"# about [[bull]]",
+			"",
+			"I like [[bull]],",
+			"it renders markdown.",
+			"",
+			"* [ ] try [[the wiki|the wiki]] on the phone",
+			"  * nested, without link",
+			"",
+			"![[bull]]",
+			"",
+			"I like [[bull]],",
+			"it renders markdown.",
+		}, "\n"),
+		"other.md": "see [other](bull) and [[elsewhere]]",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	t.Run("PageRefs", func(t *testing.T) {
+		want := map[string][]string{
+			"bull": {
+				"about [[bull]]",
+				"I like [[bull]], it renders markdown.",
+				"[[bull]]",
+			},
+			"the wiki": {"[ ] try [[the wiki|the wiki]] on the phone"},
+		}
+		if diff := cmp.Diff(want, idx.text.docs["notes"].contexts); diff != "" {
+			t.Errorf("notes contexts: unexpected diff (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Aliases", func(t *testing.T) {
+		want := []string{
+			"about [[bull]]",
+			"I like [[bull]], it renders markdown.",
+			"[[bull]]",
+			"[ ] try [[the wiki|the wiki]] on the phone",
+		}
+		if diff := cmp.Diff(want, b.backlinkContexts(idx, "notes", "bull")); diff != "" {
+			t.Errorf("backlinkContexts(notes, bull): unexpected diff (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Render", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.Handle("/{page...}", handleError(b.handleRender))
+		testsrv := httptest.NewServer(mux)
+		defer testsrv.Close()
+		resp, err := testsrv.Client().Get(testsrv.URL + "/bull")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			`<blockquote>
+<p>I like <a href="/bull">bull</a>, it renders markdown.</p>
+</blockquote>`,
+			`<p>see <a href="bull">other</a> and <a href="/elsewhere" class="bull_missing" title="page elsewhere does not exist">elsewhere</a></p>`,
+		} {
+			if !strings.Contains(string(body), want) {
+				t.Errorf("GET /bull: response does not contain %q", want)
+			}
+		}
+		if strings.Contains(string(body), "bull_embed") {
+			t.Errorf("GET /bull: backlink context transcluded a page")
+		}
+	})
+}
+
+func TestBacklinkSections(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"guide.md": "# Getting started\n\n## Setup\n",
+		"notes.md": "see [[guide#Getting started]] and [setup](guide#setup)\n",
+		"other.md": "just [[guide]]\n",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	if diff := cmp.Diff([]string{"Getting started", "setup"}, b.backlinkSections(idx, "notes", "guide")); diff != "" {
+		t.Errorf("backlinkSections(notes, guide): unexpected diff (-want +got):\n%s", diff)
+	}
+	if got := b.backlinkSections(idx, "other", "guide"); len(got) > 0 {
+		t.Errorf("backlinkSections(other, guide) = %q, want none", got)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/{page...}", handleError(b.handleRender))
+	testsrv := httptest.NewServer(mux)
+	defer testsrv.Close()
+	resp, err := testsrv.Client().Get(testsrv.URL + "/guide")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<a href="/notes">notes</a> → <a href="#getting-started">Getting started</a>, <a href="#setup">setup</a>`
+	if !strings.Contains(string(body), want) {
+		t.Errorf("GET /guide: response does not contain %q", want)
+	}
+}
@@ -0,0 +1,310 @@
+package bull
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"html/template"
+	"iter"
+	"log"
+	"maps"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gokrazy/bull/internal/frontmatter"
+)
+
+// minMentionLength is the minimum length (in runes) of names for which
+// unlinked mentions are listed: shorter names match too many words.
+const minMentionLength = 3
+
+// A mention is a line of a page which mentions another page by name
+// without linking to it.
+type mention struct {
+	matchingLine
+	PageName string // the mentioning page
+	Name     string // the first mentioned name (page name or alias)
+}
+
+// mentionNames returns the names by which pageName can be mentioned:
+// its page name, its base name (for pages in subdirectories, unless other
+// pages have the same base name, like index or notes often do) and its
+// aliases.
+func (b *bullServer) mentionNames(idx *idx, pageName string) []string {
+	names := []string{pageName}
+	base := path.Base(pageName)
+	if same := idx.resolver().byBase[base]; len(same) == 0 || (len(same) == 1 && same[0] == pageName) {
+		names = append(names, base)
+	}
+	names = append(names, b.aliasesOf(idx, pageName)...)
+	names = slices.DeleteFunc(names, func(name string) bool {
+		return utf8.RuneCountInString(name) < minMentionLength
+	})
+	// Longer names first, so that “projects/bull” is preferred over “bull”.
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(b), len(a)), strings.Compare(a, b))
+	})
+	return slices.Compact(names)
+}
+
+// linkedRegexp matches the parts of a line in which a mention is not
+// unlinked: links, inline code, HTML tags and URLs.
+var linkedRegexp = regexp.MustCompile("\\[\\[[^\\]]*\\]\\]|\\[[^\\]]*\\]\\([^)]*\\)|`[^`]*`|<[^>]*>|\\w+://\\S+")
+
+func isMentionBoundary(line string, start, end int) bool {
+	if r, _ := utf8.DecodeLastRuneInString(line[:start]); start > 0 && isTokenRune(r) {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(line[end:]); end < len(line) && isTokenRune(r) {
+		return false
+	}
+	return true
+}
+
+// mentionRanges returns the (sorted, non-overlapping) byte ranges of the
+// whole-word, case-insensitive matches of res in line, outside of links.
+func mentionRanges(line string, res []*regexp.Regexp) (ranges [][2]int, first int) {
+	excluded := linkedRegexp.FindAllStringIndex(line, -1)
+	first = -1
+	for idx, re := range res {
+	Match:
+		for _, loc := range re.FindAllStringIndex(line, -1) {
+			if !isMentionBoundary(line, loc[0], loc[1]) {
+				continue
+			}
+			for _, ex := range excluded {
+				if loc[0] < ex[1] && ex[0] < loc[1] {
+					continue Match
+				}
+			}
+			for _, r := range ranges {
+				if loc[0] < r[1] && r[0] < loc[1] {
+					continue Match // already matched by a longer name
+				}
+			}
+			ranges = append(ranges, [2]int{loc[0], loc[1]})
+			if first == -1 {
+				first = idx
+			}
+		}
+	}
+	slices.SortFunc(ranges, func(a, b [2]int) int { return cmp.Compare(a[0], b[0]) })
+	return ranges, first
+}
+
+func mentionRegexps(names []string) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, len(names))
+	for idx, name := range names {
+		res[idx] = regexp.MustCompile("(?i)" + regexp.QuoteMeta(name))
+	}
+	return res
+}
+
+// proseLines yields the lines of content (with 1-based line numbers),
+// skipping front matter and fenced code blocks.
+func proseLines(content string) iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		var fence string
+		lineno := 0
+		for line := range strings.SplitSeq(frontmatter.Blank(content), "\n") {
+			lineno++
+			trimmed := strings.TrimSpace(line)
+			if fence != "" {
+				if strings.HasPrefix(trimmed, fence) {
+					fence = ""
+				}
+				continue
+			}
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				fence = trimmed[:3]
+				continue
+			}
+			if !yield(lineno, line) {
+				return
+			}
+		}
+	}
+}
+
+// mentionLines returns the lines of content which mention any of names,
+// skipping front matter and fenced code blocks.
+func mentionLines(content string, names []string) []mention {
+	res := mentionRegexps(names)
+	var mentions []mention
+	for lineno, line := range proseLines(content) {
+		ranges, first := mentionRanges(line, res)
+		if len(ranges) == 0 {
+			continue
+		}
+		mentions = append(mentions, mention{
+			matchingLine: matchingLine{
+				Line:   lineno,
+				Text:   line,
+				Ranges: ranges,
+			},
+			Name: names[first],
+		})
+	}
+	return mentions
+}
+
+// unlinkedMentions returns the lines of pages which mention pageName (see
+// mentionNames), excluding pages which link to pageName.
+func (b *bullServer) unlinkedMentions(idx *idx, pageName string) []mention {
+	if idx.text == nil {
+		return nil
+	}
+	names := b.mentionNames(idx, pageName)
+	if len(names) == 0 {
+		return nil
+	}
+	candidates := make(map[string]bool)
+	for _, name := range names {
+		for candidate := range idx.text.candidates(name) {
+			candidates[candidate] = true
+		}
+	}
+	delete(candidates, pageName)
+	for _, linker := range b.backlinksTo(idx, pageName) {
+		delete(candidates, linker)
+	}
+	var mentions []mention
+	for _, candidate := range slices.Sorted(maps.Keys(candidates)) {
+		doc, ok := idx.text.docs[candidate]
+		if !ok {
+			continue
+		}
+		pg, err := b.read(doc.fileName)
+		if err != nil {
+			continue // e.g. deleted since it was indexed
+		}
+		for _, m := range mentionLines(pg.Content, names) {
+			m.PageName = candidate
+			mentions = append(mentions, m)
+		}
+	}
+	return mentions
+}
+
+// mentionsHTML renders mentions of pageName as an HTML list, which is
+// inserted into the markdown as an HTML block (so that the mentioning
+// lines are not interpreted as markdown).
+func (b *bullServer) mentionsHTML(pageName string, mentions []mention) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<ul class="bull_mentions">` + "\n")
+	for _, m := range mentions {
+		fmt.Fprintf(&buf, `<li><a href="%s%s">%s</a>:%d: %s`,
+			b.root,
+			(&page{PageName: m.PageName}).URLPath(),
+			template.HTMLEscapeString(m.PageName),
+			m.Line,
+			highlightRanges(m.Text, m.Ranges))
+		if b.editor != "" {
+			fmt.Fprintf(&buf, ` <form method="post" action="%s_linkmention/%s" class="bull_linkmention">`,
+				b.URLBullPrefix(),
+				(&page{PageName: m.PageName}).URLPath())
+			fmt.Fprintf(&buf, `<input type="hidden" name="target" value="%s">`, template.HTMLEscapeString(pageName))
+			fmt.Fprintf(&buf, `<input type="hidden" name="name" value="%s">`, template.HTMLEscapeString(m.Name))
+			fmt.Fprintf(&buf, `<input type="hidden" name="line" value="%d">`, m.Line)
+			fmt.Fprintf(&buf, `<button type="submit" title="replace with [[%s]]">link</button></form>`, template.HTMLEscapeString(pageName))
+		}
+		buf.WriteString("</li>\n")
+	}
+	buf.WriteString("</ul>\n")
+	return buf.Bytes()
+}
+
+// linkMention replaces the first unlinked mention of name in line lineno
+// of content with a wiki link to target.
+func linkMention(content string, lineno int, name, target string) (string, bool) {
+	var m *mention
+	for _, candidate := range mentionLines(content, []string{name}) {
+		if candidate.Line == lineno {
+			m = &candidate
+			break
+		}
+	}
+	if m == nil {
+		return content, false
+	}
+	r := m.Ranges[0]
+	text := m.Text[r[0]:r[1]]
+	link := "[[" + target + "]]"
+	if text != target {
+		sep := "|"
+		if strings.HasPrefix(strings.TrimSpace(m.Text), "|") {
+			sep = `\|` // within a table cell
+		}
+		link = "[[" + target + sep + text + "]]"
+	}
+	lines := strings.Split(content, "\n")
+	lines[lineno-1] = m.Text[:r[0]] + link + m.Text[r[1]:]
+	return strings.Join(lines, "\n"), true
+}
+
+func (b *bullServer) linkMentionAPI(w http.ResponseWriter, r *http.Request) error {
+	if b.editor == "" {
+		return httpError(http.StatusForbidden, fmt.Errorf("running in read-only mode (-editor= flag)"))
+	}
+	src := r.PathValue("page")
+	target := r.FormValue("target")
+	name := r.FormValue("name")
+	if target == "" || name == "" {
+		return httpError(http.StatusBadRequest, fmt.Errorf("invalid request: target= and name= parameters required"))
+	}
+	line, err := strconv.Atoi(r.FormValue("line"))
+	if err != nil {
+		return httpError(http.StatusBadRequest, fmt.Errorf("invalid line= parameter: %v", err))
+	}
+	log.Printf("linking mention (line=%d, name=%q) on page=%q to %q", line, name, src, target)
+	pg, err := b.readFirst(page2files(src))
+	if err != nil {
+		return err
+	}
+	updated, ok := linkMention(pg.DiskContent, line, name, target)
+	if !ok {
+		return httpError(http.StatusConflict, fmt.Errorf("line %d of page %q does not mention %q (anymore?)", line, pg.PageName, name))
+	}
+	if err := b.writePage(pg.PageName, updated); err != nil {
+		return err
+	}
+	http.Redirect(w, r, b.mentionsURL(target), http.StatusFound)
+	return nil
+}
+
+// mentionsURL returns the URL of the unlinked mentions of pageName.
+func (b *bullServer) mentionsURL(pageName string) string {
+	return b.URLBullPrefix() + "mentions/" + (&page{PageName: pageName}).URLPath()
+}
+
+// mentionsContent lists the unlinked mentions of pageName. They are not
+// part of the page itself (like backlinks), because finding them requires
+// scanning the content of many pages.
+func (b *bullServer) mentionsContent(pageName string) []byte {
+	<-b.idxReady
+	idx := b.idx.Load()
+	mentions := b.unlinkedMentions(idx, pageName)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# unlinked mentions: [[%s]]\n\n", pageName)
+	if len(mentions) == 0 {
+		fmt.Fprintf(&buf, "No other page mentions %s without linking to it.\n",
+			strings.Join(b.mentionNames(idx, pageName), ", "))
+		return buf.Bytes()
+	}
+	buf.Write(b.mentionsHTML(pageName, mentions))
+	return buf.Bytes()
+}
+
+func (b *bullServer) mentions(w http.ResponseWriter, r *http.Request) error {
+	pageName := strings.Trim(r.PathValue("page"), "/")
+	if pageName == "" {
+		pageName = "index"
+	}
+	return b.renderBullMarkdown(w, r, "mentions/"+pageName, bytes.NewBuffer(b.mentionsContent(pageName)))
+}
@@ -0,0 +1,207 @@
+package bull
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnlinkedMentions(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"projects/bull.md": "---\naliases: [the wiki]\n---\nbull is a wiki\n",
+		"index.md":         "see [[bull]]\n",
+		"notes.md": strings.Join([]string{
+			"---",
+			"title: bull notes",
+			"---",
+			"I like Bull a lot.",
+			"bulldozers are not bull.",
+			"a link elsewhere: [[bull market]]",
+			"```",
+			"bull in code",
+			"```",
+			"`bull` and https://example.com/bull",
+			"the wiki, and projects/bull.",
+		}, "\n"),
+		"unrelated.md": "nothing to see here",
+	})
+	b.editor = "textarea"
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	t.Run("Names", func(t *testing.T) {
+		want := []string{"projects/bull", "the wiki", "bull"}
+		if diff := cmp.Diff(want, b.mentionNames(idx, "projects/bull")); diff != "" {
+			t.Errorf("mentionNames: unexpected diff (-want +got):\n%s", diff)
+		}
+		// notes is ambiguous: another page has the same base name.
+		want = []string{"projects/notes"}
+		if diff := cmp.Diff(want, b.mentionNames(idx, "projects/notes")); diff != "" {
+			t.Errorf("mentionNames: unexpected diff (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Mentions", func(t *testing.T) {
+		got := b.unlinkedMentions(idx, "projects/bull")
+		want := []mention{
+			{
+				matchingLine: matchingLine{Line: 4, Text: "I like Bull a lot.", Ranges: [][2]int{{7, 11}}},
+				PageName:     "notes",
+				Name:         "bull",
+			},
+			{
+				matchingLine: matchingLine{Line: 5, Text: "bulldozers are not bull.", Ranges: [][2]int{{19, 23}}},
+				PageName:     "notes",
+				Name:         "bull",
+			},
+			{
+				matchingLine: matchingLine{Line: 11, Text: "the wiki, and projects/bull.", Ranges: [][2]int{{0, 8}, {14, 27}}},
+				PageName:     "notes",
+				Name:         "projects/bull",
+			},
+		}
+		if diff := cmp.Diff(want, got, cmp.AllowUnexported(mention{})); diff != "" {
+			t.Errorf("unlinkedMentions: unexpected diff (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Link", func(t *testing.T) {
+		for _, tt := range []struct {
+			content string
+			line    int
+			name    string
+			want    string
+			wantOK  bool
+		}{
+			{
+				content: "a\nI like Bull a lot, bull!",
+				line:    2,
+				name:    "bull",
+				want:    "a\nI like [[projects/bull|Bull]] a lot, bull!",
+				wantOK:  true,
+			},
+			{
+				content: "see projects/bull",
+				line:    1,
+				name:    "projects/bull",
+				want:    "see [[projects/bull]]",
+				wantOK:  true,
+			},
+			{
+				content: "| bull | wiki |",
+				line:    1,
+				name:    "bull",
+				want:    `| [[projects/bull\|bull]] | wiki |`,
+				wantOK:  true,
+			},
+			{
+				content: "[[bull]] only",
+				line:    1,
+				name:    "bull",
+				want:    "[[bull]] only",
+			},
+		} {
+			got, ok := linkMention(tt.content, tt.line, tt.name, "projects/bull")
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("linkMention(%q, %d, %q) = %q, %v, want %q, %v", tt.content, tt.line, tt.name, got, ok, tt.want, tt.wantOK)
+			}
+		}
+	})
+
+	urlBullPrefix := b.URLBullPrefix()
+	mux := http.NewServeMux()
+	mux.Handle("GET "+urlBullPrefix+"mentions/{page...}", handleError(b.mentions))
+	mux.Handle("GET /{page...}", handleError(b.handleRender))
+	mux.Handle("POST "+urlBullPrefix+"_linkmention/{page...}", handleError(b.linkMentionAPI))
+	testsrv := httptest.NewServer(mux)
+	defer testsrv.Close()
+	client := testsrv.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	get := func(t *testing.T, path string) string {
+		t.Helper()
+		resp, err := client.Get(testsrv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	t.Run("Render", func(t *testing.T) {
+		// Pages link to their unlinked mentions, which are only
+		// computed on demand.
+		body := get(t, "/projects/bull")
+		if want := `<a href="/_bull/mentions/projects/bull">Unlinked mentions</a>`; !strings.Contains(body, want) {
+			t.Errorf("rendered page does not contain %q", want)
+		}
+		if strings.Contains(body, "bull_linkmention") {
+			t.Errorf("rendered page unexpectedly contains unlinked mentions")
+		}
+
+		body = get(t, "/_bull/mentions/projects/bull")
+		for _, want := range []string{
+			`<a href="/notes">notes</a>:4: I like <mark>Bull</mark> a lot.`,
+			`<form method="post" action="/_bull/_linkmention/notes" class="bull_linkmention">`,
+			`<input type="hidden" name="line" value="5">`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("rendered page does not contain %q", want)
+			}
+		}
+	})
+
+	t.Run("API", func(t *testing.T) {
+		form := url.Values{
+			"target": {"projects/bull"},
+			"name":   {"bull"},
+			"line":   {"4"},
+		}
+		resp, err := client.PostForm(testsrv.URL+"/_bull/_linkmention/notes", form)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got, want := resp.StatusCode, http.StatusFound; got != want {
+			t.Fatalf("unexpected HTTP status: got %v, want %v", got, want)
+		}
+		if got, want := resp.Header.Get("Location"), "/_bull/mentions/projects/bull"; got != want {
+			t.Errorf("unexpected redirect: got %q, want %q", got, want)
+		}
+		pg, err := b.read("notes.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := strings.Split(pg.Content, "\n")[3], "I like [[projects/bull|Bull]] a lot."; got != want {
+			t.Errorf("line 4 after linking: got %q, want %q", got, want)
+		}
+		// notes now links to projects/bull, so it is no longer listed.
+		if got := b.unlinkedMentions(b.idx.Load(), "projects/bull"); len(got) > 0 {
+			t.Errorf("unlinkedMentions after linking = %v, want none", got)
+		}
+
+		// Linking the same mention again fails: it is not unlinked anymore.
+		resp, err = client.PostForm(testsrv.URL+"/_bull/_linkmention/notes", form)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got, want := resp.StatusCode, http.StatusConflict; got != want {
+			t.Errorf("unexpected HTTP status: got %v, want %v", got, want)
+		}
+	})
+}
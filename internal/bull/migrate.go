@@ -0,0 +1,262 @@
+package bull
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// terminalTaskStates are the task states which conclude a task: done,
+// cancelled, migrated or scheduled (moved to a future log).
+const terminalTaskStates = "xX-><"
+
+// openTaskRegexp returns a regexp matching list items whose checkbox is
+// unchecked or in another non-terminal state of the task state cycle states,
+// e.g. [/] for in progress. The regexp captures the indentation.
+func openTaskRegexp(states []string) *regexp.Regexp {
+	alternatives := []string{" "}
+	for _, state := range states {
+		if state != " " && !strings.Contains(terminalTaskStates, state) {
+			alternatives = append(alternatives, regexp.QuoteMeta(state))
+		}
+	}
+	return regexp.MustCompile(`^(\s*)(?:[-*+]|\d+[.)])\s+\[(?:` + strings.Join(alternatives, "|") + `)\]`)
+}
+
+// openTask is a list item with an open checkbox, together with the more
+// indented lines following it (subtasks and notes).
+type openTask struct {
+	Line  int      // 1-based
+	Lines []string // the task line, followed by the rest of its block
+	Open  []int    // lines of the task and of its open subtasks
+}
+
+// openTasks returns the open tasks of content (see openTaskRegexp), skipping
+// front matter and fenced code blocks. Open subtasks are part of the block of
+// their parent task instead of separate tasks.
+func openTasks(content string, states []string) []openTask {
+	re := openTaskRegexp(states)
+	lines := strings.Split(content, "\n")
+	var tasks []openTask
+	end := 0 // last line of the block of the previous task
+	for lineno, line := range proseLines(content) {
+		if !re.MatchString(line) {
+			continue
+		}
+		if lineno <= end {
+			last := &tasks[len(tasks)-1]
+			last.Open = append(last.Open, lineno)
+			continue
+		}
+		end = blockEnd(lines, lineno)
+		tasks = append(tasks, openTask{
+			Line:  lineno,
+			Lines: lines[lineno-1 : end],
+			Open:  []int{lineno},
+		})
+	}
+	return tasks
+}
+
+// indentation returns the number of leading spaces and tabs of line.
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// blockEnd returns the last line (1-based) of the block starting at line
+// lineno, i.e. of the lines which are more indented than line lineno.
+// Blank lines only belong to the block if more indented lines follow.
+func blockEnd(lines []string, lineno int) int {
+	indent := indentation(lines[lineno-1])
+	end := lineno
+	for i := lineno; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		if indentation(lines[i]) <= indent {
+			break
+		}
+		end = i + 1
+	}
+	return end
+}
+
+// migrationSource is a daily journal page with open tasks.
+type migrationSource struct {
+	pg    *page
+	tasks []openTask
+}
+
+// taskMigration migrates the open tasks of previous daily journal pages
+// into the daily page of the current day, like in a paper bullet journal.
+type taskMigration struct {
+	today   string // page name of the daily page of the current day
+	sources []migrationSource
+}
+
+func (m *taskMigration) numTasks() int {
+	n := 0
+	for _, src := range m.sources {
+		for _, task := range src.tasks {
+			n += len(task.Open)
+		}
+	}
+	return n
+}
+
+// planMigration finds the open tasks of the daily journal pages before now
+// (only of the last days days if days > 0), oldest first.
+func (b *bullServer) planMigration(idx *idx, now time.Time, days int) (*taskMigration, error) {
+	if b.contentSettings.DailyPage == "" {
+		return nil, fmt.Errorf("no daily journal configured (daily_page in _bull/content-settings.toml is empty)")
+	}
+	m := &taskMigration{today: b.journalPage(now)}
+	today, _ := b.journalDate(m.today)
+	for _, d := range b.journalDays(idx) {
+		if !d.day.Before(today) {
+			break
+		}
+		if days > 0 && d.day.Before(today.AddDate(0, 0, -days)) {
+			continue
+		}
+		pg, err := b.readFirst(page2files(d.pageName))
+		if err != nil {
+			return nil, err
+		}
+		if tasks := openTasks(pg.DiskContent, b.contentSettings.TaskStates); len(tasks) > 0 {
+			m.sources = append(m.sources, migrationSource{pg: pg, tasks: tasks})
+		}
+	}
+	return m, nil
+}
+
+// dedentTasks returns the lines of tasks without their common indentation.
+func dedentTasks(tasks []openTask) []string {
+	indent := -1
+	for _, task := range tasks {
+		if n := indentation(task.Lines[0]); indent == -1 || n < indent {
+			indent = n
+		}
+	}
+	var lines []string
+	for _, task := range tasks {
+		for _, line := range task.Lines {
+			lines = append(lines, line[min(indent, indentation(line)):])
+		}
+	}
+	return lines
+}
+
+// applyMigration appends the open tasks to the daily page of the current day
+// (creating it from its template if needed, see pageTemplate), then marks
+// the tasks on their original pages as migrated ([>]) or, with move set,
+// removes them (including their subtasks) from there.
+//
+// If any of the original pages changed since planMigration read them, e.g.
+// because the page was edited in the meantime, applyMigration returns an
+// error without modifying any page.
+func (b *bullServer) applyMigration(m *taskMigration, move bool) error {
+	if len(m.sources) == 0 {
+		return nil
+	}
+	for _, src := range m.sources {
+		pg, err := b.readFirst(page2files(src.pg.PageName))
+		if err != nil {
+			return err
+		}
+		if pg.DiskContent != src.pg.DiskContent {
+			return fmt.Errorf("page %q changed since planning the migration, please try again", src.pg.PageName)
+		}
+	}
+	var content string
+	pg, err := b.readFirst(page2files(m.today))
+	switch {
+	case err == nil:
+		content = pg.DiskContent
+	case os.IsNotExist(err):
+		tmpl := cmp.Or(b.contentSettings.DailyTemplate, b.pageTemplate(m.today))
+		if tmpl != "" {
+			content, err = b.newPageContent(m.today, tmpl)
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return err
+	}
+	var sb strings.Builder
+	sb.WriteString(content)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		sb.WriteString("\n")
+	}
+	for _, src := range m.sources {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "migrated from [[%s]]:\n\n", src.pg.PageName)
+		for _, line := range dedentTasks(src.tasks) {
+			sb.WriteString(line + "\n")
+		}
+	}
+	// Write the daily page first: if updating the original pages fails,
+	// tasks are duplicated instead of lost.
+	if err := b.writePage(m.today, sb.String()); err != nil {
+		return err
+	}
+	for _, src := range m.sources {
+		updated := src.pg.DiskContent
+		if move {
+			lines := strings.Split(updated, "\n")
+			for _, task := range slices.Backward(src.tasks) {
+				lines = slices.Delete(lines, task.Line-1, task.Line-1+len(task.Lines))
+			}
+			updated = strings.Join(lines, "\n")
+		} else {
+			for _, task := range src.tasks {
+				for _, line := range task.Open {
+					updated = setCheckbox(updated, line, ">")
+				}
+			}
+		}
+		if err := b.writePage(src.pg.PageName, updated); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *bullServer) migrateAPI(w http.ResponseWriter, r *http.Request) error {
+	if b.editor == "" {
+		return httpError(http.StatusForbidden, fmt.Errorf("running in read-only mode (-editor= flag)"))
+	}
+	days := 0
+	if s := r.FormValue("days"); s != "" {
+		var err error
+		days, err = strconv.Atoi(s)
+		if err != nil || days < 0 {
+			return httpError(http.StatusBadRequest, fmt.Errorf("invalid days %q", s))
+		}
+	}
+	move := r.FormValue("move") == "true"
+	<-b.idxReady
+	// Concurrent migrations would copy the same tasks twice.
+	b.migrateMu.Lock()
+	defer b.migrateMu.Unlock()
+	m, err := b.planMigration(b.idx.Load(), time.Now(), days)
+	if err != nil {
+		return httpError(http.StatusNotFound, err)
+	}
+	log.Printf("migrating %d open tasks of %d pages to %s", m.numTasks(), len(m.sources), m.today)
+	if err := b.applyMigration(m, move); err != nil {
+		return err
+	}
+	http.Redirect(w, r, b.journalLink(m.today).URL, http.StatusFound)
+	return nil
+}
@@ -0,0 +1,223 @@
+package bull
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOpenTasks(t *testing.T) {
+	content := "---\ntitle: day\n---\n" +
+		"- [ ] open\n  notes\n\n  - [x] done subtask\n  - [ ] open subtask\n" +
+		"- [x] done\n  * [ ] nested\n" +
+		"```\n- [ ] in code\n```\n" +
+		"1. [/] in progress\n- [>] migrated\ntext [ ] in a line\n"
+	want := []openTask{
+		{Line: 4, Lines: []string{"- [ ] open", "  notes", "", "  - [x] done subtask", "  - [ ] open subtask"}, Open: []int{4, 8}},
+		{Line: 10, Lines: []string{"  * [ ] nested"}, Open: []int{10}},
+		{Line: 14, Lines: []string{"1. [/] in progress"}, Open: []int{14}},
+	}
+	if diff := cmp.Diff(want, openTasks(content, []string{" ", "/", "x"})); diff != "" {
+		t.Errorf("openTasks: unexpected diff (-want +got):\n%s", diff)
+	}
+	// [/] is not part of the default task state cycle.
+	if diff := cmp.Diff(want[:2], openTasks(content, nil)); diff != "" {
+		t.Errorf("openTasks(default states): unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestSetCheckbox(t *testing.T) {
+	content := "# tasks\n- [ ] buy milk\n- [x] call plumber"
+	if got, want := setCheckbox(content, 2, ">"), "# tasks\n- [>] buy milk\n- [x] call plumber"; got != want {
+		t.Errorf("setCheckbox(2) = %q, want %q", got, want)
+	}
+	for _, line := range []int{0, 1, 4} {
+		if got := setCheckbox(content, line, ">"); got != content {
+			t.Errorf("setCheckbox(%d) = %q, want content unchanged", line, got)
+		}
+	}
+	if got, want := toggleCheckbox(content, 3, nil), "# tasks\n- [ ] buy milk\n- [ ] call plumber"; got != want {
+		t.Errorf("toggleCheckbox(3) = %q, want %q", got, want)
+	}
+}
+
+func TestMigrateTasks(t *testing.T) {
+	now := time.Now()
+	day := func(offset int) string {
+		return "days/" + now.AddDate(0, 0, offset).Format(time.DateOnly)
+	}
+	newBull := func(t *testing.T) *bullServer {
+		b := newTestBull(t, map[string]string{
+			day(-9) + ".md":      "- [ ] ancient task\n",
+			day(-2) + ".md":      "# plans\n\n- [ ] call plumber\n  - [ ] ask for quote\n- [x] buy milk\n",
+			day(-1) + ".md":      "  - [ ] indented task\nnotes",
+			day(0) + ".md":       "# today",
+			"notes.md":           "- [ ] not a daily page",
+			"_templates/days.md": "# {{title}}\n",
+			"days/not-a-date.md": "- [ ] nope",
+			day(1) + ".md":       "- [ ] tomorrow's task",
+		})
+		b.contentSettings.DailyPage = "days/2006-01-02"
+		idx, err := b.index()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.idx.Store(idx)
+		return b
+	}
+	read := func(t *testing.T, b *bullServer, pageName string) string {
+		t.Helper()
+		pg, err := b.readFirst(page2files(pageName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pg.DiskContent
+	}
+
+	t.Run("Plan", func(t *testing.T) {
+		b := newBull(t)
+		m, err := b.planMigration(b.idx.Load(), now, 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, src := range m.sources {
+			got = append(got, src.pg.PageName)
+		}
+		if diff := cmp.Diff([]string{day(-2), day(-1)}, got); diff != "" {
+			t.Errorf("planMigration: unexpected sources (-want +got):\n%s", diff)
+		}
+		if got, want := m.numTasks(), 3; got != want {
+			t.Errorf("numTasks = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("Copy", func(t *testing.T) {
+		b := newBull(t)
+		m, err := b.planMigration(b.idx.Load(), now, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.applyMigration(m, false); err != nil {
+			t.Fatal(err)
+		}
+		for pageName, want := range map[string]string{
+			day(0): "# today\n" +
+				"\nmigrated from [[" + day(-9) + "]]:\n\n- [ ] ancient task\n" +
+				"\nmigrated from [[" + day(-2) + "]]:\n\n- [ ] call plumber\n  - [ ] ask for quote\n" +
+				"\nmigrated from [[" + day(-1) + "]]:\n\n- [ ] indented task\n",
+			day(-2): "# plans\n\n- [>] call plumber\n  - [>] ask for quote\n- [x] buy milk\n",
+			day(-1): "  - [>] indented task\nnotes",
+			day(1):  "- [ ] tomorrow's task",
+		} {
+			if diff := cmp.Diff(want, read(t, b, pageName)); diff != "" {
+				t.Errorf("%s: unexpected diff (-want +got):\n%s", pageName, diff)
+			}
+		}
+		// The daily page of today now links to the pages it migrated from.
+		if diff := cmp.Diff([]string{day(0)}, b.idx.Load().backlinks[day(-2)]); diff != "" {
+			t.Errorf("backlinks: unexpected diff (-want +got):\n%s", diff)
+		}
+
+		// Migrating again finds nothing to do.
+		m, err = b.planMigration(b.idx.Load(), now, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.numTasks(); got != 0 {
+			t.Errorf("second migration: numTasks = %d, want 0", got)
+		}
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		b := newBull(t)
+		// Without an existing daily page, its template is used.
+		if err := b.content.Remove(page2desired(day(0))); err != nil {
+			t.Fatal(err)
+		}
+		m, err := b.planMigration(b.idx.Load(), now, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.applyMigration(m, true); err != nil {
+			t.Fatal(err)
+		}
+		for pageName, want := range map[string]string{
+			day(0): "# " + now.Format(time.DateOnly) + "\n" +
+				"\nmigrated from [[" + day(-2) + "]]:\n\n- [ ] call plumber\n  - [ ] ask for quote\n" +
+				"\nmigrated from [[" + day(-1) + "]]:\n\n- [ ] indented task\n",
+			// Subtasks are moved together with their task.
+			day(-2): "# plans\n\n- [x] buy milk\n",
+			day(-1): "notes",
+		} {
+			if diff := cmp.Diff(want, read(t, b, pageName)); diff != "" {
+				t.Errorf("%s: unexpected diff (-want +got):\n%s", pageName, diff)
+			}
+		}
+	})
+
+	t.Run("Changed", func(t *testing.T) {
+		b := newBull(t)
+		m, err := b.planMigration(b.idx.Load(), now, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The page is edited after planning the migration.
+		edited := "# plans\n\n- [x] call plumber\n"
+		if err := b.writePage(day(-2), edited); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.applyMigration(m, true); err == nil {
+			t.Fatalf("applyMigration unexpectedly succeeded")
+		}
+		for pageName, want := range map[string]string{
+			day(0):  "# today",
+			day(-2): edited,
+			day(-1): "  - [ ] indented task\nnotes",
+		} {
+			if diff := cmp.Diff(want, read(t, b, pageName)); diff != "" {
+				t.Errorf("%s: unexpected diff (-want +got):\n%s", pageName, diff)
+			}
+		}
+	})
+
+	t.Run("API", func(t *testing.T) {
+		b := newBull(t)
+		mux := http.NewServeMux()
+		mux.Handle("POST /_bull/_migrate", handleError(b.migrateAPI))
+		testsrv := httptest.NewServer(mux)
+		defer testsrv.Close()
+		client := testsrv.Client()
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+
+		resp, err := client.Post(testsrv.URL+"/_bull/_migrate", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got, want := resp.StatusCode, http.StatusForbidden; got != want {
+			t.Errorf("read-only: unexpected HTTP status: got %v, want %v", got, want)
+		}
+
+		b.editor = "textarea"
+		resp, err = client.Post(testsrv.URL+"/_bull/_migrate?days=1", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got, want := resp.StatusCode, http.StatusFound; got != want {
+			t.Fatalf("unexpected HTTP status: got %v, want %v", got, want)
+		}
+		if got, want := resp.Header.Get("Location"), "/"+day(0); got != want {
+			t.Errorf("unexpected redirect: got %q, want %q", got, want)
+		}
+		if got, want := read(t, b, day(-1)), "  - [>] indented task\nnotes"; got != want {
+			t.Errorf("%s after migration: got %q, want %q", day(-1), got, want)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package bull
+
+import (
+	"fmt"
+	"html/template"
+	"sync"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+	"go.abhg.dev/goldmark/wikilink"
+)
+
+// pageExists reports whether target (of a wiki link) refers to an existing
+// page (or alias), or to another file or directory in the content directory.
+func (b *bullServer) pageExists(target string) bool {
+	if target == "" {
+		return true // link within the same page, e.g. [[#section]]
+	}
+	select {
+	case <-b.idxReady:
+		idx := b.idx.Load()
+		if _, ok := idx.links[file2page(target)]; ok {
+			return true
+		}
+		if _, ok := b.resolveAlias(idx, target); ok {
+			return true
+		}
+	default:
+		// The index is not ready yet, fall back to the file system.
+	}
+	// The index might lag behind the file system (or not contain target,
+	// e.g. for images), so check the file system before declaring target
+	// missing.
+	for _, fn := range append(page2files(target), target) {
+		if _, err := b.content.Stat(fn); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// wikilinkRenderer renders wiki links to missing pages with a CSS class
+// (see ContentSettings.MissingLinkClass) and a title explaining why. All
+// other wiki links are rendered by the wikilink extension.
+type wikilinkRenderer struct {
+	wikilink.Renderer
+
+	exists func(target string) bool
+	class  string
+	title  string
+
+	missing sync.Map // *wikilink.Node => struct{}
+}
+
+func (r *wikilinkRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(wikilink.Kind, r.render)
+}
+
+func (r *wikilinkRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n, ok := node.(*wikilink.Node)
+	if !ok {
+		return ast.WalkStop, fmt.Errorf("unexpected node %T, expected *wikilink.Node", node)
+	}
+	if !entering {
+		if _, ok := r.missing.LoadAndDelete(n); ok {
+			w.WriteString("</a>")
+			return ast.WalkContinue, nil
+		}
+		return r.Renderer.Render(w, source, node, entering)
+	}
+	if n.Embed || r.exists(string(n.Target)) {
+		return r.Renderer.Render(w, source, node, entering)
+	}
+	dest, err := r.Resolver.ResolveWikilink(n)
+	if err != nil {
+		return ast.WalkStop, fmt.Errorf("resolve %q: %w", n.Target, err)
+	}
+	r.missing.Store(n, struct{}{})
+	fmt.Fprintf(w, `<a href="%s" class="%s" title="%s">`,
+		util.URLEscape(dest, true /* resolve references */),
+		template.HTMLEscapeString(r.class),
+		template.HTMLEscapeString(fmt.Sprintf(r.title, n.Target)))
+	return ast.WalkContinue, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"maps"
 	"net/http"
 	"strings"
 	"time"
@@ -42,30 +43,45 @@ func (b *bullServer) opensearch(w http.ResponseWriter, r *http.Request) error {
 
 }
 
+// suggestPageNames is the number of fuzzy page name matches
+// included in search suggestions.
+const suggestPageNames = 5
+
 func (b *bullServer) suggest(w http.ResponseWriter, r *http.Request) error {
-	query := r.FormValue("q")
-	if query == "" {
-		return httpError(http.StatusBadRequest, fmt.Errorf("empty q= parameter not allowed"))
-	}
-	if len(query) < 2 {
-		return httpError(http.StatusBadRequest, fmt.Errorf("minimum query length: 2 characters"))
+	q, err := parseQuery(r)
+	if err != nil {
+		return err
 	}
 
 	ctx := r.Context()
 	start := time.Now()
-	results, err := b.internalsearch(ctx, query, nil)
+	results, err := b.internalsearch(ctx, q, nil)
 	if err != nil {
 		return err
 	}
-	log.Printf("search for query %q done in %v, now streaming results", query, time.Since(start))
+	log.Printf("search for query %q done in %v, now streaming results", q, time.Since(start))
 
-	suggestions := make([]string, len(results))
-	for idx, result := range results {
-		suggestions[idx] = result.PageName
+	// Page name matches come first, so that the browser offers to
+	// navigate to a page even when the query has a typo in it.
+	var names []fuzzyMatch
+	if q.Regexp() == nil {
+		<-b.idxReady
+		names = fuzzyPages(maps.Keys(b.idx.Load().links), r.FormValue("q"), suggestPageNames)
+	}
+	suggestions := make([]string, 0, len(names)+len(results))
+	seen := make(map[string]bool, len(names))
+	for _, m := range names {
+		suggestions = append(suggestions, m.PageName)
+		seen[m.PageName] = true
+	}
+	for _, result := range results {
+		if !seen[result.PageName] {
+			suggestions = append(suggestions, result.PageName)
+		}
 	}
 	var buf bytes.Buffer
 	if err := json.NewEncoder(&buf).Encode([]any{
-		query,
+		q.String(),
 		suggestions,
 	}); err != nil {
 		return fmt.Errorf("encoding response: %v", err)
@@ -2,12 +2,15 @@ package bull
 
 import (
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/gokrazy/bull/internal/frontmatter"
 )
 
 // A page is the logical unit of content that bull works with.
@@ -26,6 +29,9 @@ type page struct {
 	DiskContent string
 	Content     string
 
+	// Meta is the parsed front matter of Content (nil if none).
+	Meta *frontmatter.FrontMatter
+
 	Class string // extra CSS class (can be empty)
 }
 
@@ -129,9 +135,21 @@ func (b *bullServer) read(file string) (*page, error) {
 		ModTime:     fi.ModTime(),
 		DiskContent: string(diskContent),
 		Content:     string(content),
+		Meta:        parseFrontMatter(file, string(content)),
 	}, nil
 }
 
+// parseFrontMatter returns the front matter of content. Invalid front matter
+// is logged and treated like regular content (which is then rendered).
+func parseFrontMatter(file, content string) *frontmatter.FrontMatter {
+	fm, err := frontmatter.Parse(content)
+	if err != nil {
+		log.Printf("%s: %v", file, err)
+		return nil
+	}
+	return fm
+}
+
 // pageFromURL returns the requested content page name from the HTTP request.
 //
 // Special case: an empty page name (URL /) resolves to index.
@@ -0,0 +1,73 @@
+package bull
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// templatesDir contains template pages for new pages: new pages in
+// directory meetings start with the content of _templates/meetings
+// (or of the template of the closest parent directory).
+const templatesDir = "_templates"
+
+// pageTemplate returns the name of the template page for the new page
+// pageName, or the empty string if there is none. Templates configured in
+// ContentSettings.Templates take precedence over the templatesDir convention.
+func (b *bullServer) pageTemplate(pageName string) string {
+	if strings.HasPrefix(pageName, templatesDir+"/") {
+		return "" // templates start empty
+	}
+	// The most specific (longest) matching pattern wins.
+	var best string
+	for pattern := range b.contentSettings.Templates {
+		if ok, _ := path.Match(pattern, pageName); !ok {
+			continue
+		}
+		if len(pattern) > len(best) ||
+			(len(pattern) == len(best) && pattern < best) {
+			best = pattern
+		}
+	}
+	if best != "" {
+		return b.contentSettings.Templates[best]
+	}
+	for dir := path.Dir(pageName); dir != "."; dir = path.Dir(dir) {
+		tmpl := path.Join(templatesDir, dir)
+		if _, err := b.readFirst(page2files(tmpl)); err == nil {
+			return tmpl
+		}
+	}
+	return ""
+}
+
+// expandTemplate replaces the placeholders in the content of a template
+// page for the new page pageName:
+//
+//	{{date}}    the current date, e.g. 2026-10-18
+//	{{title}}   the base name of pageName, e.g. standup for meetings/standup
+//	{{parent}}  the directory of pageName, e.g. meetings (index for top-level pages)
+//	{{page}}    pageName itself, e.g. meetings/standup
+func expandTemplate(content, pageName string, now time.Time) string {
+	parent := path.Dir(pageName)
+	if parent == "." {
+		parent = "index"
+	}
+	return strings.NewReplacer(
+		"{{date}}", now.Format(time.DateOnly),
+		"{{title}}", path.Base(pageName),
+		"{{parent}}", parent,
+		"{{page}}", pageName,
+	).Replace(content)
+}
+
+// newPageContent returns the initial content of the new page pageName,
+// which is the (expanded) content of the template page tmpl.
+func (b *bullServer) newPageContent(pageName, tmpl string) (string, error) {
+	tmplpg, err := b.readFirst(page2files(tmpl))
+	if err != nil {
+		return "", fmt.Errorf("reading template page: %w", err)
+	}
+	return expandTemplate(tmplpg.DiskContent, pageName, time.Now()), nil
+}
@@ -0,0 +1,105 @@
+package bull
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPageTemplate(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"_templates/meetings.md":   "attendees: {{parent}}",
+		"_templates/projects.md":   "project {{title}}",
+		"templates/retro.md":       "went well:",
+		"templates/oneonone.md":    "topics:",
+		"meetings/2026/standup.md": "exists",
+	})
+	b.contentSettings.Templates = map[string]string{
+		"meetings/*retro*": "templates/retro",
+		"meetings/1on1-*":  "templates/oneonone",
+		"*":                "templates/toplevel",
+	}
+	for _, tt := range []struct {
+		pageName string
+		want     string
+	}{
+		{"meetings/2026-10-18 retro", "templates/retro"},
+		{"meetings/1on1-alice", "templates/oneonone"},
+		{"notes", "templates/toplevel"},
+		// templatesDir convention, including parent directories
+		{"meetings/standup", "_templates/meetings"},
+		{"meetings/2026/planning", "_templates/meetings"},
+		{"projects/bull/design", "_templates/projects"},
+		// no template
+		{"recipes/pancakes", ""},
+		{"_templates/recipes", ""},
+	} {
+		if got := b.pageTemplate(tt.pageName); got != tt.want {
+			t.Errorf("pageTemplate(%q) = %q, want %q", tt.pageName, got, tt.want)
+		}
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.Local)
+	tmpl := "# {{title}}\n\n{{date}}, see [[{{parent}}]] ({{page}}) {{unknown}}"
+	for _, tt := range []struct {
+		pageName string
+		want     string
+	}{
+		{
+			pageName: "meetings/standup",
+			want:     "# standup\n\n2026-10-18, see [[meetings]] (meetings/standup) {{unknown}}",
+		},
+		{
+			pageName: "notes",
+			want:     "# notes\n\n2026-10-18, see [[index]] (notes) {{unknown}}",
+		},
+	} {
+		if got := expandTemplate(tmpl, tt.pageName, now); got != tt.want {
+			t.Errorf("expandTemplate(%q) = %q, want %q", tt.pageName, got, tt.want)
+		}
+	}
+}
+
+func TestEditNewPageFromTemplate(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"_templates/meetings.md": "attendees of {{title}}",
+	})
+	b.editor = "textarea"
+	if err := b.init(); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /_bull/edit/{page...}", handleError(b.edit))
+	testsrv := httptest.NewServer(mux)
+	defer testsrv.Close()
+
+	for _, tt := range []struct {
+		path string
+		want string
+	}{
+		{"/_bull/edit/meetings/standup", "attendees of standup"},
+		// New template pages start empty.
+		{"/_bull/edit/_templates/meetings/weekly", "const BullMarkdown = ``;"},
+	} {
+		resp, err := testsrv.Client().Get(testsrv.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := resp.StatusCode, http.StatusOK; got != want {
+			t.Fatalf("%s: unexpected HTTP status: got %v, want %v", tt.path, got, want)
+		}
+		if !strings.Contains(string(body), tt.want) {
+			t.Errorf("%s: edit page does not contain %q:\n%s", tt.path, tt.want, body)
+		}
+	}
+}
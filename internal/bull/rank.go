@@ -0,0 +1,129 @@
+package bull
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gokrazy/bull/internal/query"
+)
+
+// Ranking parameters. Content matches are scored with Okapi BM25, see
+// https://en.wikipedia.org/wiki/Okapi_BM25, then boosted by title matches
+// and recency.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// headingWeight is how much more an occurrence in a heading counts.
+	headingWeight = 3
+
+	// Title boosts are large compared to typical BM25 scores so that page
+	// name matches are listed first, like before ranking was introduced.
+	titleExactBoost  = 20 // page name equals the query
+	titlePrefixBoost = 10 // page name starts with the query
+	titleTermBoost   = 5  // page name contains a query term (per term)
+
+	// recencyBoost is added in full for pages modified just now and decays
+	// with a half-life of recencyHalfLife.
+	recencyBoost    = 1
+	recencyHalfLife = 30 * 24 * time.Hour
+)
+
+type ranker struct {
+	query     string // positive terms of the query, separated by spaces
+	terms     []string
+	idf       map[string]float64
+	avgLength float64
+	now       time.Time
+}
+
+// newRanker returns a ranker for the results of q, given the candidates of
+// each term of q (see termCandidates).
+func (t *textIndex) newRanker(q *query.Query, now time.Time, termCandidates map[string]map[string]bool) *ranker {
+	n := float64(len(t.docs))
+	terms := q.Terms()
+	idf := make(map[string]float64, len(terms))
+	for _, term := range terms {
+		candidates, ok := termCandidates[term]
+		if !ok {
+			candidates = t.candidates(term)
+		}
+		// The number of candidates is an upper bound for the number of
+		// documents containing the term, which is good enough for ranking.
+		df := float64(len(candidates))
+		idf[term] = math.Log(1 + (n-df+0.5)/(df+0.5))
+	}
+	avgLength := 1.0
+	if len(t.docs) > 0 && t.totalLength > 0 {
+		avgLength = float64(t.totalLength) / n
+	}
+	return &ranker{
+		query:     strings.Join(terms, " "),
+		terms:     terms,
+		idf:       idf,
+		avgLength: avgLength,
+		now:       now,
+	}
+}
+
+func isHeading(line string) bool {
+	return strings.HasPrefix(line, "#") &&
+		strings.HasPrefix(strings.TrimLeft(line, "#"), " ")
+}
+
+// termFrequencies returns how often each term occurs in content, counting
+// occurrences in headings headingWeight times.
+func (r *ranker) termFrequencies(content string) map[string]float64 {
+	tf := make(map[string]float64, len(r.terms))
+	for line := range strings.SplitSeq(content, "\n") {
+		linel := strings.ToLower(line)
+		weight := 1.0
+		if isHeading(line) {
+			weight = headingWeight
+		}
+		for _, term := range r.terms {
+			if n := strings.Count(linel, term); n > 0 {
+				tf[term] += weight * float64(n)
+			}
+		}
+	}
+	return tf
+}
+
+// score returns the relevance of doc, whose content is content.
+func (r *ranker) score(doc *textDoc, content string) float64 {
+	var score float64
+
+	// Content relevance (BM25).
+	tf := r.termFrequencies(content)
+	norm := bm25K1 * (1 - bm25B + bm25B*float64(doc.length)/r.avgLength)
+	for _, term := range r.terms {
+		f := tf[term]
+		score += r.idf[term] * f * (bm25K1 + 1) / (f + norm)
+	}
+
+	// Title matches. Filters and negated terms (e.g. -foo) do not count,
+	// so that bull -foo still boosts the page bull.
+	name := strings.ToLower(doc.pageName)
+	switch {
+	case r.query == "":
+		// no terms, e.g. for filter-only or regexp queries
+	case name == r.query:
+		score += titleExactBoost
+	case strings.HasPrefix(name, r.query):
+		score += titlePrefixBoost
+	}
+	for _, term := range r.terms {
+		if strings.Contains(name, term) {
+			score += titleTermBoost
+		}
+	}
+
+	// Recency.
+	if age := r.now.Sub(doc.modTime); age >= 0 {
+		score += recencyBoost * math.Exp2(-float64(age)/float64(recencyHalfLife))
+	}
+
+	return score
+}
@@ -0,0 +1,63 @@
+package bull
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gokrazy/bull/internal/query"
+)
+
+func TestRank(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	contents := map[string]string{
+		"once":      "the garden has one bull in it, and many other animals as well",
+		"often":     "bull bull bull, the bull is big",
+		"heading":   "# bull\n\nthe garden has many other animals as well",
+		"old":       "bull bull bull, the bull is big",
+		"bull":      "nothing to see here",
+		"bullfrog":  "nothing to see here",
+		"unrelated": "nothing to see here",
+	}
+	docs := make(map[string]*textDoc)
+	for name, content := range contents {
+		var age time.Duration
+		if name == "old" {
+			age = 365 * 24 * time.Hour
+		}
+		docs[name] = newTextDoc(&page{
+			PageName: name,
+			Content:  content,
+			ModTime:  now.Add(-age),
+		}, nil)
+	}
+	text := newTextIndex(docs)
+	for _, raw := range []string{
+		"bull",
+		// Filters and negated terms do not prevent title boosts.
+		"bull -garden",
+		"bull -path:old",
+	} {
+		q, err := query.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := text.newRanker(q, now, text.termCandidates(q.Terms()))
+
+		for _, tt := range []struct {
+			higher, lower string
+			reason        string
+		}{
+			{"often", "once", "term frequency"},
+			{"heading", "once", "heading match"},
+			{"often", "old", "recency"},
+			{"bull", "bullfrog", "exact title match"},
+			{"bullfrog", "often", "title match"},
+		} {
+			higher := r.score(docs[tt.higher], contents[tt.higher])
+			lower := r.score(docs[tt.lower], contents[tt.lower])
+			if higher <= lower {
+				t.Errorf("%s: %s: score(%s) = %f, want > score(%s) = %f", raw, tt.reason, tt.higher, higher, tt.lower, lower)
+			}
+		}
+	}
+}
@@ -106,7 +106,7 @@ func (b *bullServer) renameAPI(w http.ResponseWriter, r *http.Request) error {
 	if err != nil {
 		return err
 	}
-	newTargets, err := b.linkTargets(newPg)
+	newRefs, err := b.pageRefs(newPg)
 	if err != nil {
 		return fmt.Errorf("index update after rename: %v", err)
 	}
@@ -117,30 +117,36 @@ func (b *bullServer) renameAPI(w http.ResponseWriter, r *http.Request) error {
 		pageName string
 		targets  []string
 	}
-	var linkerUpdates []linkerUpdate
+	var (
+		linkerUpdates []linkerUpdate
+		docs          = []*textDoc{newTextDoc(newPg, newRefs)}
+	)
 	for _, linker := range linkers {
 		linkerpg, err := b.readFirst(page2files(linker))
 		if err != nil {
 			log.Printf("rename: re-index linker %s: %v", linker, err)
 			continue
 		}
-		targets, err := b.linkTargets(linkerpg)
+		refs, err := b.pageRefs(linkerpg)
 		if err != nil {
-			log.Printf("rename: linkTargets for linker %s: %v", linker, err)
+			log.Printf("rename: pageRefs for linker %s: %v", linker, err)
 			continue
 		}
-		linkerUpdates = append(linkerUpdates, linkerUpdate{linkerpg.PageName, targets})
+		linkerUpdates = append(linkerUpdates, linkerUpdate{linkerpg.PageName, refs.targets})
+		docs = append(docs, newTextDoc(linkerpg, refs))
 	}
 
 	// Update index atomically: single clone-patch-store cycle
 	// to prevent fswatch from interleaving partial state.
 	updates := make([]indexUpdate, 0, 1+len(linkerUpdates))
-	updates = append(updates, indexUpdate{destPage, newTargets})
+	updates = append(updates, indexUpdate{destPage, newRefs.targets})
 	for _, lu := range linkerUpdates {
 		updates = append(updates, indexUpdate(lu))
 	}
 	b.idxMu.Lock()
 	b.applyIndexBatchLocked([]string{pg.PageName}, updates)
+	b.updateTextIndexLocked(nil, docs)
+	b.reresolveLocked([]string{pg.PageName, destPage})
 	b.idxMu.Unlock()
 	// Notify outside idxMu to maintain consistent lock ordering
 	// (idxMu is never held when acquiring contentChangedMu).
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/gokrazy/bull/internal/assets"
+	"github.com/gokrazy/bull/internal/frontmatter"
 	"github.com/gokrazy/bull/internal/hashtag"
 	"github.com/gokrazy/bull/internal/itasklist"
 	"github.com/gokrazy/bull/internal/linkify"
@@ -26,12 +27,16 @@ import (
 	"github.com/yuin/goldmark/renderer"
 	"github.com/yuin/goldmark/renderer/html"
 	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
 	"go.abhg.dev/goldmark/wikilink"
 )
 
 type resolver struct {
 	root        string
 	contentRoot *os.Root
+	// resolve returns the page which a wiki link target refers to,
+	// see ContentSettings.RelativeLinks.
+	resolve func(target string) string
 }
 
 func (r *resolver) ResolveWikilink(n *wikilink.Node) (destination []byte, err error) {
@@ -41,12 +46,25 @@ func (r *resolver) ResolveWikilink(n *wikilink.Node) (destination []byte, err er
 	//
 	// This allows creating pages by linking to them, following the link,
 	// then clicking Create page in the top menu bar.
-	return append([]byte(r.root), []byte((&url.URL{Path: string(n.Target)}).EscapedPath())...), nil
+	target := string(n.Target)
+	if r.resolve != nil {
+		target = r.resolve(target)
+	}
+	return append([]byte(r.root), []byte((&url.URL{Path: target}).EscapedPath())...), nil
 }
 
 func (b *bullServer) converter(pg *page) goldmark.Markdown {
 	var parserOpts []parser.Option
 	parserOpts = append(parserOpts, parser.WithAutoHeadingID())
+	wikilinkResolver := &resolver{
+		root:        b.root,
+		contentRoot: b.content,
+	}
+	if b.contentSettings.RelativeLinks && pg != nil {
+		wikilinkResolver.resolve = func(target string) string {
+			return b.resolveTarget(b.idx.Load(), pg.PageName, target)
+		}
+	}
 	var rendererOpts []renderer.Option
 	if b.contentSettings.HardWraps {
 		// Turn newlines into <br>.
@@ -54,32 +72,50 @@ func (b *bullServer) converter(pg *page) goldmark.Markdown {
 	}
 	// Allow inline HTML e.g. for the page rename form.
 	rendererOpts = append(rendererOpts, html.WithUnsafe())
+	rendererOpts = append(rendererOpts, renderer.WithNodeRenderers(
+		util.Prioritized(&embedRenderer{root: b.root}, 500)))
+	if class := b.contentSettings.MissingLinkClass; class != "" {
+		// Takes precedence over the renderer of the wikilink extension
+		// (priority 199, lower values take precedence), which it
+		// delegates to for existing pages.
+		title := "page %s does not exist"
+		if b.editor != "" {
+			title = "page %s does not exist yet, follow the link to create it"
+		}
+		rendererOpts = append(rendererOpts, renderer.WithNodeRenderers(
+			util.Prioritized(&wikilinkRenderer{
+				Renderer: wikilink.Renderer{Resolver: wikilinkResolver},
+				exists: func(target string) bool {
+					if wikilinkResolver.resolve != nil {
+						target = wikilinkResolver.resolve(target)
+					}
+					return b.pageExists(target)
+				},
+				class: class,
+				title: title,
+			}, 100)))
+	}
 	extensions := []goldmark.Extender{
 		// extension.GFM is defined as
 		// Linkify, Table, Strikethrough and TaskList
 		// We need to pass custom options to Linkify.
+		// Task lists are rendered by itasklist (see below).
 		&linkify.Extender{},
 		extension.Table,
 		extension.Strikethrough,
 		&wikilink.Extender{
-			Resolver: &resolver{
-				root:        b.root,
-				contentRoot: b.content,
-			},
+			Resolver: wikilinkResolver,
 		},
 		wikilinkpipe.Extender{},
 		&hashtag.Extender{
 			URLBullPrefix: b.URLBullPrefix(),
 		},
 	}
-	if b.contentSettings.InteractiveTaskList && b.editor != "" {
-		extensions = append(extensions, &itasklist.Extender{
-			URLBullPrefix: b.URLBullPrefix(),
-			PageURLPath:   pg.URLPath(),
-		})
-	} else {
-		extensions = append(extensions, extension.TaskList)
-	}
+	extensions = append(extensions, &itasklist.Extender{
+		URLBullPrefix: b.URLBullPrefix(),
+		PageURLPath:   pg.URLPath(),
+		Disabled:      !b.contentSettings.InteractiveTaskList || b.editor == "",
+	})
 	if b.customization != nil && b.customization.GoldmarkExtensionsFor != nil {
 		extensions = append(extensions, b.customization.GoldmarkExtensionsFor(&CustomizationContext{
 			HardWraps: b.contentSettings.HardWraps,
@@ -94,7 +130,9 @@ func (b *bullServer) converter(pg *page) goldmark.Markdown {
 
 func (b *bullServer) parseMD(pg *page, md string) ast.Node {
 	converter := b.converter(pg)
-	source := []byte(md)
+	// Blanking the front matter retains byte offsets,
+	// so the document can be rendered from md.
+	source := []byte(frontmatter.Blank(md))
 	doc := converter.Parser().Parse(text.NewReader(source))
 
 	// Make the URL protocol default to http:// for naked links
@@ -113,6 +151,8 @@ func (b *bullServer) parseMD(pg *page, md string) ast.Node {
 		return ast.WalkContinue, nil
 	})
 
+	renderBlockIDs(doc, source)
+
 	// Wrap all ast.List nodes with a itasklist.Kind node.
 	// NOTE: Until https://github.com/yuin/goldmark/pull/523 is fixed,
 	// we cannot replace the current node in ast.Walk.
@@ -136,9 +176,16 @@ func (b *bullServer) parseMD(pg *page, md string) ast.Node {
 }
 
 func (b *bullServer) render(pg *page, md string) string {
+	return b.renderContent(pg, md, []string{pg.PageName})
+}
+
+// renderContent renders md (the content of pg or a section thereof),
+// which is embedded within the pages on stack.
+func (b *bullServer) renderContent(pg *page, md string, stack []string) string {
 	var buf bytes.Buffer
 
 	doc := b.parseMD(pg, md)
+	b.transclude(pg, doc, stack)
 	converter := b.converter(pg)
 	err := converter.Renderer().Render(&buf, []byte(md), doc)
 	if err != nil {
@@ -193,7 +240,14 @@ func (b *bullServer) handleRender(w http.ResponseWriter, r *http.Request) error
 		err = b.serveStaticFile(w, r)
 		if os.IsNotExist(err) {
 			// Neither a page nor a static file exists
-			// with this name. Render a not found error.
+			// with this name. Maybe the name is an alias?
+			<-b.idxReady
+			if canonical, ok := b.resolveAlias(b.idx.Load(), pageFromURL(r)); ok {
+				target := b.root + (&page{PageName: canonical}).URLPath()
+				http.Redirect(w, r, target, http.StatusFound)
+				return nil
+			}
+			// Render a not found error.
 			return b.renderNotFound(w, r)
 		}
 		return err
@@ -208,16 +262,33 @@ func (b *bullServer) renderWithBacklinks(w http.ResponseWriter, r *http.Request,
 	wb := []byte(pg.Content)
 
 	<-b.idxReady
-	if linkers := b.idx.Load().backlinks[pg.PageName]; len(linkers) > 0 {
+	idx := b.idx.Load()
+	var anchors []string // for linking to sections
+	if idx.text != nil {
+		if doc, ok := idx.text.docs[pg.PageName]; ok {
+			anchors = doc.anchors
+		}
+	}
+	if linkers := b.backlinksTo(idx, pg.PageName); len(linkers) > 0 {
 		wb = append(wb, []byte(`
 # backlinks
 
 `)...)
 		for _, linker := range linkers {
-			wb = append(wb, fmt.Appendf(nil, "* [[%s]]\n", file2page(linker))...)
+			wb = append(wb, fmt.Appendf(nil, "* [[%s]]", file2page(linker))...)
+			if sections := b.backlinkSections(idx, linker, pg.PageName); len(sections) > 0 {
+				var links []string
+				for _, section := range sections {
+					links = append(links, fmt.Sprintf("[%s](#%s)", section, anchorID(anchors, section)))
+				}
+				wb = append(wb, fmt.Appendf(nil, " → %s", strings.Join(links, ", "))...)
+			}
+			wb = append(wb, '\n')
+			for _, snippet := range b.backlinkContexts(idx, linker, pg.PageName) {
+				wb = append(wb, fmt.Appendf(nil, "\n  > %s\n", snippet)...)
+			}
 		}
 	}
-
 	return b.renderMarkdown(w, r, pg, wb)
 }
 
@@ -286,6 +357,7 @@ func (b *bullServer) renderMarkdown(w http.ResponseWriter, r *http.Request, pg *
 		StaticHash    func(string) string
 		MermaidHash   string
 		Watch         string
+		Journal       *journalNav
 	}{
 		URLPrefix:     b.root,
 		URLBullPrefix: b.URLBullPrefix(),
@@ -294,9 +366,10 @@ func (b *bullServer) renderMarkdown(w http.ResponseWriter, r *http.Request, pg *
 		Title:         insideOutTitle(pg.FileName, b.contentDir),
 		Page:          pg,
 		Content:       template.HTML(html),
-		ContentHash:   pg.ContentHash(),
+		ContentHash:   b.contentHash(pg),
 		StaticHash:    b.staticHash,
 		MermaidHash:   hashSum(mermaid.BullMermaid),
 		Watch:         b.watch,
+		Journal:       b.journalNav(pg),
 	})
 }
@@ -1,15 +1,18 @@
 package bull
 
 import (
+	"bytes"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/gokrazy/bull/internal/query"
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/tools/txtar"
 )
@@ -327,6 +330,9 @@ func TestWikilinkLabels(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
+			// The link targets do not exist, which is not what this test
+			// is about (see TestMissingLinks).
+			cs.MissingLinkClass = ""
 			idxReady := make(chan struct{})
 			close(idxReady)
 			bull := &bullServer{
@@ -458,3 +464,206 @@ func TestLinkify(t *testing.T) {
 		}
 	}
 }
+
+func TestFrontMatter(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"pancakes.md": "---\n" +
+			"title: Fluffy pancakes\n" +
+			"tags: [recipe, breakfast]\n" +
+			"date: 2026-02-01\n" +
+			"---\n" +
+			"flour, eggs, milk\n",
+		"waffles.md": "+++\n" +
+			"title = \"Waffles | crispy\"\n" +
+			"tags = [\"recipe\", \"sweet|savory\"]\n" +
+			"date = 2026-01-15\n" +
+			"+++\n" +
+			"waffle iron required\n",
+		"notes.md": "no front matter\n",
+		"broken.md": "---\n" +
+			"title: [unterminated\n" +
+			"---\n",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	mux := http.NewServeMux()
+	mux.Handle("/{page...}", handleError(b.handleRender))
+	testsrv := httptest.NewServer(mux)
+	defer testsrv.Close()
+
+	get := func(path string) string {
+		t.Helper()
+		resp, err := testsrv.Client().Get(testsrv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	t.Run("Render", func(t *testing.T) {
+		body := get("/pancakes")
+		for _, want := range []string{
+			`<h1 class="bull_title">Fluffy pancakes</h1>`,
+			`Created: 2026-02-01`,
+			`<a href="/_bull/tag/breakfast">#breakfast</a>`,
+			`<p>flour, eggs, milk</p>`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("GET /pancakes: response does not contain %q", want)
+			}
+		}
+		if strings.Contains(body, "date: 2026") {
+			t.Errorf("GET /pancakes: response contains front matter")
+		}
+
+		// Invalid front matter is rendered like regular content.
+		if body, want := get("/broken"), "title: [unterminated"; !strings.Contains(body, want) {
+			t.Errorf("GET /broken: response does not contain %q", want)
+		}
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		if diff := cmp.Diff([]string{"pancakes", "waffles"}, idx.text.tags["recipe"]); diff != "" {
+			t.Errorf("unexpected pages tagged #recipe: diff (-want +got):\n%s", diff)
+		}
+		// Front matter tags can contain |, which separates table cells.
+		if want := "| [\\#sweet\\|savory](/_bull/tag/sweet%7Csavory) | 1 |\n"; !strings.Contains(string(b.tagsContent()), want) {
+			t.Errorf("tags page does not contain %q:\n%s", want, b.tagsContent())
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		for _, tt := range []struct {
+			query string
+			want  []string
+		}{
+			{query: "meta:title=fluffy", want: []string{"pancakes"}},
+			{query: "meta:date<2026-02", want: []string{"waffles"}},
+			{query: "-meta:date", want: []string{"broken", "notes"}},
+		} {
+			q, err := query.Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			results, err := b.internalsearch(t.Context(), q, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, m := range results {
+				got = append(got, m.PageName)
+			}
+			slices.Sort(got)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("search(%q): unexpected results: diff (-want +got):\n%s", tt.query, diff)
+			}
+		}
+	})
+
+	t.Run("BrowseSort", func(t *testing.T) {
+		md, err := b.browseContent("", "meta:date", "desc", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for line := range strings.SplitSeq(string(md), "\n") {
+			if name, _, ok := strings.Cut(strings.TrimPrefix(line, "| [["), "]]"); ok {
+				got = append(got, name)
+			}
+		}
+		want := []string{"pancakes", "waffles", "broken", "notes"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("browse sorted by meta:date: unexpected order: diff (-want +got):\n%s", diff)
+		}
+		if want := "[[pancakes]] • date: 2026-02-01"; !strings.Contains(string(md), want) {
+			t.Errorf("browse sorted by meta:date does not contain %q", want)
+		}
+
+		md, err = b.browseContent("", "meta:title", "asc", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "| [[waffles]] • title: Waffles \\| crispy | <time"; !strings.Contains(string(md), want) {
+			t.Errorf("browse sorted by meta:title does not contain %q:\n%s", want, md)
+		}
+	})
+}
+
+func TestMissingLinks(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"index.md":    "[[exists]] [[nowhere|gone]] [[crêpes]] [[diagram.png]] [[#top]]",
+		"exists.md":   "---\naliases: [crêpes]\n---\nhello",
+		"diagram.png": "not really a PNG",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	render := func() string {
+		t.Helper()
+		pg, err := b.read("index.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := b.converter(pg).Renderer().Render(&buf, []byte(pg.Content), b.parseMD(pg, pg.Content)); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	got := render()
+	for _, want := range []string{
+		`<a href="/exists">exists</a>`,
+		`<a href="/nowhere" class="bull_missing" title="page nowhere does not exist">gone</a>`,
+		`<a href="/cr%C3%AApes">crêpes</a>`,
+		`<a href="/diagram.png">diagram.png</a>`,
+		`<a href="/">#top</a>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("rendered HTML does not contain %q:\n%s", want, got)
+		}
+	}
+
+	b.contentSettings.MissingLinkClass = ""
+	if got := render(); strings.Contains(got, "bull_missing") {
+		t.Errorf("rendered HTML contains missing link class despite empty MissingLinkClass:\n%s", got)
+	}
+}
+
+func TestRenderBlockIDs(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"guide.md": "install bull ^step-1\n\n" +
+			"- tight item ^item\n" +
+			"- *emphasized* ^emph\n\n" +
+			"two lines\nof text ^two-lines\n\n" +
+			"not a ^marker here\n",
+	})
+	pg, err := b.read("guide.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := b.render(pg, pg.Content)
+	for _, want := range []string{
+		`<p id="^step-1">install bull</p>`,
+		`<li id="^item">tight item</li>`,
+		`<li id="^emph"><em>emphasized</em></li>`,
+		"<p id=\"^two-lines\">two lines<br>\nof text</p>",
+		`<p>not a ^marker here</p>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("rendered HTML does not contain %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package bull
+
+import (
+	"cmp"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// linkResolver resolves wiki link targets relative to the linking page,
+// see ContentSettings.RelativeLinks.
+type linkResolver struct {
+	pages map[string][]string // idx.links: only the keys (page names) are used
+	// byBase maps base names (e.g. design) to the page names with that
+	// base name (e.g. design, projects/bull/design), shortest first.
+	byBase map[string][]string
+}
+
+func newLinkResolver(pages map[string][]string) *linkResolver {
+	byBase := make(map[string][]string)
+	for pageName := range pages {
+		base := path.Base(pageName)
+		byBase[base] = append(byBase[base], pageName)
+	}
+	for _, names := range byBase {
+		slices.SortFunc(names, func(a, b string) int {
+			return cmp.Or(cmp.Compare(len(a), len(b)), strings.Compare(a, b))
+		})
+	}
+	return &linkResolver{
+		pages:  pages,
+		byBase: byBase,
+	}
+}
+
+// resolve returns the name of the page which the wiki link target on page
+// from refers to, which is (in this order):
+//
+//  1. relative to the directory of from for targets starting with ./ or ../
+//  2. the page next to from, e.g. projects/bull/design for [[design]] on
+//     projects/bull/notes
+//  3. the page with the shortest name ending in target, if there is only
+//     one, e.g. projects/bull/design for [[bull/design]] on index
+//  4. target itself, i.e. relative to the content root (like without
+//     ContentSettings.RelativeLinks)
+func (r *linkResolver) resolve(from, target string) string {
+	if target == "" || strings.Contains(target, "://") {
+		return target
+	}
+	dir := path.Dir(from)
+	if strings.HasPrefix(target, "./") || strings.HasPrefix(target, "../") {
+		return path.Join(dir, target)
+	}
+	if dir != "." {
+		if sibling := path.Join(dir, target); r.exists(sibling) {
+			return sibling
+		}
+	}
+	var matches []string
+	for _, pageName := range r.byBase[path.Base(target)] {
+		if pageName == target || strings.HasSuffix(pageName, "/"+target) {
+			matches = append(matches, pageName)
+		}
+	}
+	if len(matches) == 1 ||
+		(len(matches) > 1 && len(matches[0]) < len(matches[1])) {
+		return matches[0]
+	}
+	return target
+}
+
+func (r *linkResolver) exists(pageName string) bool {
+	_, ok := r.pages[pageName]
+	return ok
+}
+
+// lazyLinkResolver builds the linkResolver of an idx on first use.
+type lazyLinkResolver struct {
+	once     sync.Once
+	resolver *linkResolver
+}
+
+// resolver returns the linkResolver for the pages of i.
+func (i *idx) resolver() *linkResolver {
+	if i.names == nil {
+		return newLinkResolver(i.links)
+	}
+	i.names.once.Do(func() {
+		i.names.resolver = newLinkResolver(i.links)
+	})
+	return i.names.resolver
+}
+
+// resolveTarget returns the name of the page (or file) which the wiki link
+// target on page from refers to. Unless ContentSettings.RelativeLinks is
+// set, this is always target itself.
+func (b *bullServer) resolveTarget(idx *idx, from, target string) string {
+	if !b.contentSettings.RelativeLinks || idx == nil {
+		return target
+	}
+	resolved := idx.resolver().resolve(from, target)
+	if resolved != target || idx.resolver().exists(target) {
+		return resolved
+	}
+	// target might refer to a file which is not a page, e.g. an image
+	// next to from.
+	if dir := path.Dir(from); dir != "." {
+		sibling := path.Join(dir, target)
+		if _, err := b.content.Stat(sibling); err == nil {
+			return sibling
+		}
+	}
+	return target
+}
+
+// resolveRefs returns refs (of page from) with wiki link targets resolved
+// using resolveTarget. Other link targets (of markdown links) are always
+// relative to the content root.
+//
+// Where links resolve to depends on which pages exist, so when pages are
+// added or removed, reresolveLocked resolves the references of the affected
+// pages again, like rendering does.
+func (b *bullServer) resolveRefs(idx *idx, from string, refs *pageRefs) *pageRefs {
+	if !b.contentSettings.RelativeLinks || idx == nil {
+		return refs
+	}
+	resolved := make(map[string]string)
+	for _, target := range refs.wikilinks {
+		if r := b.resolveTarget(idx, from, target); r != target {
+			resolved[target] = r
+		}
+	}
+	if len(resolved) == 0 {
+		return refs
+	}
+	rename := func(target string) string {
+		if r, ok := resolved[target]; ok {
+			return r
+		}
+		return target
+	}
+	renameAll := func(targets []string) []string {
+		renamed := make([]string, 0, len(targets))
+		for _, target := range targets {
+			renamed = append(renamed, rename(target))
+		}
+		slices.Sort(renamed)
+		return slices.Compact(renamed)
+	}
+	renameKeys := func(m map[string][]string, sorted bool) map[string][]string {
+		renamed := make(map[string][]string, len(m))
+		for target, values := range m {
+			key := rename(target)
+			if existing, ok := renamed[key]; ok {
+				values = append(slices.Clone(existing), values...)
+				if sorted {
+					slices.Sort(values)
+				}
+				values = slices.Compact(values)
+			}
+			renamed[key] = values
+		}
+		return renamed
+	}
+	result := *refs
+	result.unresolved = refs
+	result.targets = renameAll(refs.targets)
+	result.wikilinks = renameAll(refs.wikilinks)
+	result.contexts = renameKeys(refs.contexts, false)
+	result.fragments = renameKeys(refs.fragments, true)
+	return &result
+}
+
+// reresolveLocked resolves the wiki link targets of all pages again which
+// might refer to one of pageNames, which were added to or removed from the
+// index: e.g. [[design]] on projects/bull/notes refers to
+// projects/bull/design once that page exists.
+// Caller must hold b.idxMu.
+func (b *bullServer) reresolveLocked(pageNames []string) {
+	if !b.contentSettings.RelativeLinks || len(pageNames) == 0 {
+		return
+	}
+	idx := b.idx.Load()
+	if idx.text == nil {
+		return
+	}
+	// Targets can only resolve to pages with the same base name.
+	bases := make(map[string]bool, len(pageNames))
+	for _, pageName := range pageNames {
+		bases[path.Base(pageName)] = true
+	}
+	var (
+		updates []indexUpdate
+		docs    []*textDoc
+	)
+	for pageName, doc := range idx.text.docs {
+		written := doc.unresolved
+		if written == nil {
+			written = &pageRefs{
+				targets:   idx.links[pageName],
+				contexts:  doc.contexts,
+				fragments: doc.fragments,
+				wikilinks: doc.wikilinks,
+			}
+		}
+		if !slices.ContainsFunc(written.wikilinks, func(target string) bool {
+			return bases[path.Base(target)]
+		}) {
+			continue
+		}
+		refs := b.resolveRefs(idx, pageName, written)
+		if slices.Equal(refs.targets, idx.links[pageName]) && slices.Equal(refs.wikilinks, doc.wikilinks) {
+			continue
+		}
+		updated := *doc
+		updated.contexts = refs.contexts
+		updated.fragments = refs.fragments
+		updated.wikilinks = refs.wikilinks
+		updated.unresolved = refs.unresolved
+		updates = append(updates, indexUpdate{pageName, refs.targets})
+		docs = append(docs, &updated)
+	}
+	if len(updates) == 0 {
+		return
+	}
+	b.applyIndexBatchLocked(nil, updates)
+	b.updateTextIndexLocked(nil, docs)
+}
+
+// linksTo returns a function which reports whether a wiki link target on
+// page linker refers to pageName.
+func (b *bullServer) linksTo(idx *idx, linker, pageName string) func(target string) bool {
+	return func(target string) bool {
+		return b.resolveTarget(idx, linker, target) == pageName
+	}
+}
@@ -0,0 +1,185 @@
+package bull
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLinkResolver(t *testing.T) {
+	r := newLinkResolver(map[string][]string{
+		"index":                 nil,
+		"design":                nil,
+		"projects/bull/design":  nil,
+		"projects/bull/notes":   nil,
+		"projects/bull/roadmap": nil,
+		"projects/gus/roadmap":  nil,
+		"archive/bull/roadmap":  nil,
+		"projects/gus/notes":    nil,
+	})
+	for _, tt := range []struct {
+		from, target string
+		want         string
+	}{
+		// sibling first
+		{"projects/bull/notes", "design", "projects/bull/design"},
+		{"projects/gus/notes", "roadmap", "projects/gus/roadmap"},
+		// explicitly relative
+		{"projects/bull/notes", "./design", "projects/bull/design"},
+		{"projects/bull/notes", "../gus/notes", "projects/gus/notes"},
+		// shortest match across the index
+		{"index", "design", "design"},
+		{"projects/gus/notes", "design", "design"},
+		{"index", "bull/design", "projects/bull/design"},
+		{"index", "gus/roadmap", "projects/gus/roadmap"},
+		{"index", "bull/roadmap", "archive/bull/roadmap"},
+		// ambiguous: falls back to the content root
+		{"index", "roadmap", "roadmap"},
+		// no match
+		{"projects/bull/notes", "nowhere", "nowhere"},
+		// left alone
+		{"projects/bull/notes", "", ""},
+		{"projects/bull/notes", "https://example.com/design", "https://example.com/design"},
+	} {
+		if got := r.resolve(tt.from, tt.target); got != tt.want {
+			t.Errorf("resolve(%q, %q) = %q, want %q", tt.from, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestRelativeLinks(t *testing.T) {
+	files := map[string]string{
+		"index.md":                  "[[bull/design]] and [[roadmap]]",
+		"roadmap.md":                "top-level roadmap",
+		"projects/bull/design.md":   "# Goals\n\nsmall",
+		"projects/bull/notes.md":    "see [[design#Goals]] and [[roadmap]]\n\n![[diagram.png]]",
+		"projects/bull/diagram.png": "not really a PNG",
+		"projects/bull/roadmap.md":  "bull roadmap",
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		b := newTestBull(t, files)
+		idx, err := b.index()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"design", "diagram.png", "roadmap"}
+		if diff := cmp.Diff(want, idx.links["projects/bull/notes"]); diff != "" {
+			t.Errorf("links: unexpected diff (-want +got):\n%s", diff)
+		}
+	})
+
+	b := newTestBull(t, files)
+	b.contentSettings.RelativeLinks = true
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	t.Run("Index", func(t *testing.T) {
+		for pageName, want := range map[string][]string{
+			"index":               {"projects/bull/design", "roadmap"},
+			"projects/bull/notes": {"projects/bull/design", "projects/bull/diagram.png", "projects/bull/roadmap"},
+		} {
+			if diff := cmp.Diff(want, idx.links[pageName]); diff != "" {
+				t.Errorf("links[%s]: unexpected diff (-want +got):\n%s", pageName, diff)
+			}
+		}
+		want := []string{"index", "projects/bull/notes"}
+		if diff := cmp.Diff(want, idx.backlinks["projects/bull/design"]); diff != "" {
+			t.Errorf("backlinks: unexpected diff (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]string{"Goals"}, b.backlinkSections(idx, "projects/bull/notes", "projects/bull/design")); diff != "" {
+			t.Errorf("backlinkSections: unexpected diff (-want +got):\n%s", diff)
+		}
+		// Only the image is reported: graph only considers pages.
+		wantBroken := []brokenLink{{Source: "projects/bull/notes", Target: "projects/bull/diagram.png"}}
+		if diff := cmp.Diff(wantBroken, b.analyzeGraph(idx).BrokenLinks); diff != "" {
+			t.Errorf("analyzeGraph: unexpected broken links: diff (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Render", func(t *testing.T) {
+		pg, err := b.read("projects/bull/notes.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := b.converter(pg).Renderer().Render(&buf, []byte(pg.Content), b.parseMD(pg, pg.Content)); err != nil {
+			t.Fatal(err)
+		}
+		got := buf.String()
+		for _, want := range []string{
+			`<a href="/projects/bull/design">design#Goals</a>`,
+			`<a href="/projects/bull/roadmap">roadmap</a>`,
+			`src="/projects/bull/diagram.png"`,
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("rendered HTML does not contain %q:\n%s", want, got)
+			}
+		}
+		if strings.Contains(got, "bull_missing") {
+			t.Errorf("rendered HTML unexpectedly contains missing links:\n%s", got)
+		}
+	})
+
+	t.Run("AddRemove", func(t *testing.T) {
+		b := newTestBull(t, map[string]string{
+			"projects/bull/notes.md": "see [[architecture]]",
+		})
+		b.contentSettings.RelativeLinks = true
+		idx, err := b.index()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.idx.Store(idx)
+		links := func(want ...string) {
+			t.Helper()
+			if diff := cmp.Diff(want, b.idx.Load().links["projects/bull/notes"]); diff != "" {
+				t.Errorf("links: unexpected diff (-want +got):\n%s", diff)
+			}
+		}
+		links("architecture")
+
+		// Adding a page changes where existing links resolve to.
+		if err := b.writePage("projects/bull/architecture", "layers"); err != nil {
+			t.Fatal(err)
+		}
+		links("projects/bull/architecture")
+		if diff := cmp.Diff([]string{"projects/bull/notes"}, b.idx.Load().backlinks["projects/bull/architecture"]); diff != "" {
+			t.Errorf("backlinks: unexpected diff (-want +got):\n%s", diff)
+		}
+
+		// So does removing it.
+		if err := b.content.Remove("projects/bull/architecture.md"); err != nil {
+			t.Fatal(err)
+		}
+		b.removeFromIndex("projects/bull/architecture")
+		links("architecture")
+	})
+
+	t.Run("Mv", func(t *testing.T) {
+		if err := b.replaceLinks("projects/bull/notes.md", "projects/bull/design", "projects/bull/architecture"); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.replaceLinks("index.md", "roadmap", "plan"); err != nil {
+			t.Fatal(err)
+		}
+		for fn, want := range map[string]string{
+			// [[roadmap]] refers to projects/bull/roadmap, not roadmap
+			"projects/bull/notes.md": "see [[projects/bull/architecture#Goals]] and [[roadmap]]\n\n![[diagram.png]]",
+			"index.md":               "[[bull/design]] and [[plan]]",
+		} {
+			pg, err := b.read(fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, pg.DiskContent); diff != "" {
+				t.Errorf("%s: unexpected diff (-want +got):\n%s", fn, diff)
+			}
+		}
+	})
+}
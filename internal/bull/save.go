@@ -26,6 +26,17 @@ func (b *bullServer) save(w http.ResponseWriter, r *http.Request) error {
 	md = strings.ReplaceAll(md, "\r\n", "\n")
 
 	pageName := pageFromURL(r)
+	if err := b.writePage(pageName, md); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, b.root+pageName, http.StatusFound)
+	return nil
+}
+
+// writePage atomically replaces the content of pageName with md (creating
+// the page if needed) and updates the index.
+func (b *bullServer) writePage(pageName, md string) error {
 	possibilities := page2files(pageName)
 
 	var firstFn string
@@ -56,21 +67,19 @@ func (b *bullServer) save(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	// Update backlink index
+	// Update backlink and search index
 	<-b.idxReady
 	pg, err := b.read(firstFn)
 	if err != nil {
 		log.Printf("index update after save: read: %v", err)
 	} else {
-		targets, err := b.linkTargets(pg)
+		refs, err := b.pageRefs(pg)
 		if err != nil {
-			log.Printf("index update after save: linkTargets: %v", err)
+			log.Printf("index update after save: pageRefs: %v", err)
 		} else {
-			b.updateIndex(pg.PageName, targets)
+			b.indexPage(pg, refs)
 		}
 	}
 	b.notifyContentChanged()
-
-	http.Redirect(w, r, b.root+pageName, http.StatusFound)
 	return nil
 }
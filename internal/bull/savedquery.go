@@ -0,0 +1,94 @@
+package bull
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gokrazy/bull/internal/query"
+)
+
+// savedQueryPrefix is where saved queries (see ContentSettings.Queries)
+// are rendered, below bullPrefix.
+const savedQueryPrefix = "query/"
+
+// savedQueryContent returns the generated markdown for the saved query
+// name, or a list of all saved queries if name is empty.
+func (b *bullServer) savedQueryContent(ctx context.Context, name string) ([]byte, error) {
+	var buf bytes.Buffer
+	if name == "" {
+		fmt.Fprintf(&buf, "# saved queries\n\n")
+		if len(b.contentSettings.Queries) == 0 {
+			fmt.Fprintf(&buf, "No saved queries defined. Add a `[queries]` table to `%scontent-settings.toml`.\n", bullPrefix)
+			return buf.Bytes(), nil
+		}
+		for _, name := range slices.Sorted(maps.Keys(b.contentSettings.Queries)) {
+			fmt.Fprintf(&buf, "* [%s](%s%s%s): %s\n",
+				name,
+				b.URLBullPrefix(),
+				savedQueryPrefix,
+				(&page{PageName: name}).URLPath(),
+				codeSpan(b.contentSettings.Queries[name]))
+		}
+		return buf.Bytes(), nil
+	}
+
+	raw, ok := b.contentSettings.Queries[name]
+	if !ok {
+		return nil, httpError(http.StatusNotFound, fmt.Errorf("saved query %q not found in %scontent-settings.toml", name, bullPrefix))
+	}
+	q, err := query.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("saved query %q: %v", name, err)
+	}
+	results, err := b.internalsearch(ctx, q, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pageNames := make([]string, len(results))
+	for idx, m := range results {
+		pageNames[idx] = m.PageName
+	}
+
+	fmt.Fprintf(&buf, "# saved query: %s\n\n", name)
+	fmt.Fprintf(&buf, "query: %s • [open in search](%s)\n\n", codeSpan(raw), b.searchURL(raw, false, 1))
+	fmt.Fprintf(&buf, "%d matching pages\n\n", len(results))
+	writeRecentTable(&buf, b.idx.Load().text, pageNames)
+	return buf.Bytes(), nil
+}
+
+// codeSpan returns s as a markdown code span, whose backtick string is
+// longer than any run of backticks in s, so that s can contain backticks.
+func codeSpan(s string) string {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	// One leading and trailing space is stripped from code spans, which
+	// allows content that starts or ends with a backtick.
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") ||
+		(strings.HasPrefix(s, " ") && strings.HasSuffix(s, " ")) {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+func (b *bullServer) savedQuery(w http.ResponseWriter, r *http.Request) error {
+	name := r.PathValue("name")
+	md, err := b.savedQueryContent(r.Context(), name)
+	if err != nil {
+		return err
+	}
+	return b.renderBullMarkdown(w, r, savedQueryPrefix+name, bytes.NewBuffer(md))
+}
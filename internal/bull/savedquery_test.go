@@ -0,0 +1,163 @@
+package bull
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yuin/goldmark"
+)
+
+func TestSavedQuery(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"_bull/content-settings.toml": `
+[queries]
+open-tasks = "has:task -path:archive/"
+`,
+		"index.md":          "- [ ] water the plants",
+		"projects/bull.md":  "- [ ] write docs\n- [x] write code",
+		"archive/old.md":    "- [ ] forgotten",
+		"notes/no-tasks.md": "nothing to do",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET "+b.URLBullPrefix()+"query/{name...}", handleError(b.savedQuery))
+	mux.Handle("GET "+b.URLBullPrefix()+"watch/{page...}", handleError(b.handleWatch))
+	testsrv := httptest.NewServer(mux)
+	defer testsrv.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest("GET", testsrv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "text/markdown")
+		resp, err := testsrv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	{
+		code, body := get("/_bull/query/open-tasks")
+		if got, want := code, http.StatusOK; got != want {
+			t.Fatalf("unexpected HTTP status: got %v, want %v", got, want)
+		}
+		for _, want := range []string{
+			"2 matching pages",
+			`<a href="/index">index</a>`,
+			`<a href="/projects/bull">projects/bull</a>`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("response does not contain %q", want)
+			}
+		}
+		if strings.Contains(body, "archive/old") {
+			t.Errorf("response unexpectedly contains excluded page archive/old")
+		}
+	}
+
+	{
+		code, body := get("/_bull/query/")
+		if got, want := code, http.StatusOK; got != want {
+			t.Fatalf("unexpected HTTP status: got %v, want %v", got, want)
+		}
+		if want := `href="/_bull/query/open-tasks"`; !strings.Contains(body, want) {
+			t.Errorf("response does not contain %q", want)
+		}
+	}
+
+	{
+		code, _ := get("/_bull/query/unknown")
+		if got, want := code, http.StatusNotFound; got != want {
+			t.Errorf("unexpected HTTP status: got %v, want %v", got, want)
+		}
+	}
+
+	// The watch stream reports a change once the query results change.
+	md, err := b.savedQueryContent(context.Background(), "open-tasks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", testsrv.URL+"/_bull/watch/_bull/query/open-tasks?hash="+hashSum(md), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := testsrv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	events := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.HasPrefix(line, "data: ") {
+				events <- line
+			}
+		}
+		close(events)
+	}()
+
+	// No need to wait for the watcher to subscribe: changes before the
+	// subscription are caught by the initial hash check.
+	b.indexPage(&page{
+		PageName: "notes/no-tasks",
+		FileName: "notes/no-tasks.md",
+		Content:  "- [ ] now there is something to do",
+		ModTime:  time.Now(),
+	}, &pageRefs{})
+	b.notifyContentChanged()
+
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatalf("watch stream ended without change event")
+		}
+		if want := `data: {"changed":true}`; ev != want {
+			t.Errorf("unexpected event: got %q, want %q", ev, want)
+		}
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for change event")
+	}
+}
+
+func TestCodeSpan(t *testing.T) {
+	for _, s := range []string{
+		"has:task",
+		"`",
+		"a`b",
+		"``a` b``",
+		" leading and trailing ",
+		"TODO(`x`)",
+	} {
+		var buf bytes.Buffer
+		if err := goldmark.Convert([]byte(codeSpan(s)), &buf); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := buf.String(), "<p><code>"+html.EscapeString(s)+"</code></p>\n"; got != want {
+			t.Errorf("codeSpan(%q) = %q, rendered as %q, want %q", s, codeSpan(s), got, want)
+		}
+	}
+}
@@ -1,25 +1,215 @@
 package bull
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
+	"html/template"
 	"log"
 	"net/http"
+	"net/url"
+	"regexp"
 	"runtime"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/gokrazy/bull/internal/query"
 	"golang.org/x/sync/errgroup"
 )
 
+const (
+	searchResultsPerPage = 20
+	searchLinesPerResult = 10
+
+	// regexpSearchTimeout bounds how long a regular expression search may
+	// take. RE2 guarantees linear time matching, but complex expressions
+	// over a large content directory can still take a while.
+	regexpSearchTimeout = 10 * time.Second
+)
+
+// searchResult is a search match prepared for the server-rendered
+// search page.
+type searchResult struct {
+	PageName  string
+	URLPath   string
+	Score     float64
+	Lines     []searchResultLine
+	MoreLines int // number of matching lines not included in Lines
+}
+
+type searchResultLine struct {
+	Line int           // 1-based, 0 for page name matches
+	URL  string        // page URL, pointing to the nearest heading
+	HTML template.HTML // highlighted
+}
+
+// matchRanges returns the byte ranges [start, end) of all occurrences of terms
+// (which must be lower-case) in line, sorted and merged where they overlap.
+func matchRanges(line string, terms []string) [][2]int {
+	linel := strings.ToLower(line)
+	if len(linel) != len(line) {
+		// Lower-casing changed byte offsets (e.g. for U+0130),
+		// so the offsets would not be valid for line.
+		return nil
+	}
+	var ranges [][2]int
+	for _, term := range terms {
+		if term == "" {
+			continue
+		}
+		for offset := 0; ; {
+			idx := strings.Index(linel[offset:], term)
+			if idx == -1 {
+				break
+			}
+			start := offset + idx
+			ranges = append(ranges, [2]int{start, start + len(term)})
+			offset = start + len(term)
+		}
+	}
+	slices.SortFunc(ranges, func(a, b [2]int) int {
+		return cmp.Compare(a[0], b[0])
+	})
+	merged := ranges[:0]
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r[0] <= merged[n-1][1] {
+			merged[n-1][1] = max(merged[n-1][1], r[1])
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// highlight returns line as HTML, with all occurrences of terms
+// wrapped in <mark> elements.
+func highlight(line string, terms []string) template.HTML {
+	return highlightRanges(line, matchRanges(line, terms))
+}
+
+// highlightRanges returns line as HTML, with the specified byte ranges
+// (as returned by matchRanges) wrapped in <mark> elements.
+func highlightRanges(line string, ranges [][2]int) template.HTML {
+	var buf strings.Builder
+	var last int
+	for _, r := range ranges {
+		buf.WriteString(template.HTMLEscapeString(line[last:r[0]]))
+		buf.WriteString("<mark>")
+		buf.WriteString(template.HTMLEscapeString(line[r[0]:r[1]]))
+		buf.WriteString("</mark>")
+		last = r[1]
+	}
+	buf.WriteString(template.HTMLEscapeString(line[last:]))
+	return template.HTML(buf.String())
+}
+
+// searchURL returns the URL of the server-rendered search results page.
+func (b *bullServer) searchURL(q string, isRegexp bool, pageNum int) string {
+	v := url.Values{"q": []string{q}}
+	if isRegexp {
+		v.Set("regexp", "1")
+	}
+	if pageNum > 1 {
+		v.Set("page", strconv.Itoa(pageNum))
+	}
+	return (&url.URL{
+		Path:     b.URLBullPrefix() + "search",
+		RawQuery: v.Encode(),
+	}).String()
+}
+
 func (b *bullServer) search(w http.ResponseWriter, r *http.Request) error {
-	// TODO: implement server-rendered version for non-javascript,
-	// i.e. POST /_bull/search handler
 	const pageName = bullPrefix + "search"
+	raw := r.FormValue("q")
+	isRegexp := r.FormValue("regexp") == "1"
+	pageNum := 1
+	if v := r.FormValue("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return httpError(http.StatusBadRequest, fmt.Errorf("invalid page= parameter %q", v))
+		}
+		pageNum = n
+	}
+
+	var (
+		searchErr  string
+		results    []searchResult
+		numResults int
+		numPages   int
+		prevURL    string
+		nextURL    string
+		createPage bool
+	)
+	if raw != "" {
+		// Render results on the server so that search works without
+		// JavaScript (text browsers, e-readers, …).
+		q, err := parseQuery(r)
+		if err != nil {
+			if he, ok := err.(*httpErr); ok {
+				w.WriteHeader(he.code)
+				searchErr = he.err.Error()
+			} else {
+				return err
+			}
+		} else {
+			matches, err := b.internalsearch(r.Context(), q, nil)
+			if err != nil {
+				return err
+			}
+			numResults = len(matches)
+			numPages = (numResults + searchResultsPerPage - 1) / searchResultsPerPage
+			createPage = b.editor != "" && !isRegexp
+			for _, m := range matches {
+				if m.PageName == raw {
+					createPage = false
+					break
+				}
+			}
+			start := min((pageNum-1)*searchResultsPerPage, numResults)
+			end := min(start+searchResultsPerPage, numResults)
+			for _, m := range matches[start:end] {
+				lines := m.Lines
+				var more int
+				if len(lines) > searchLinesPerResult {
+					more = len(lines) - searchLinesPerResult
+					lines = lines[:searchLinesPerResult]
+				}
+				urlPath := (&page{PageName: m.PageName}).URLPath()
+				resultLines := make([]searchResultLine, len(lines))
+				for idx, line := range lines {
+					u := b.root + urlPath
+					if line.HeadingID != "" {
+						u += "#" + line.HeadingID
+					}
+					resultLines[idx] = searchResultLine{
+						Line: line.Line,
+						URL:  u,
+						HTML: highlightRanges(line.Text, line.Ranges),
+					}
+				}
+				results = append(results, searchResult{
+					PageName:  m.PageName,
+					URLPath:   urlPath,
+					Score:     m.Score,
+					Lines:     resultLines,
+					MoreLines: more,
+				})
+			}
+			if pageNum > 1 {
+				prevURL = b.searchURL(raw, isRegexp, pageNum-1)
+			}
+			if pageNum < numPages {
+				nextURL = b.searchURL(raw, isRegexp, pageNum+1)
+			}
+		}
+	}
+
 	return b.executeTemplate(w, "search.html.tmpl", struct {
 		URLPrefix     string
 		URLBullPrefix string
@@ -28,7 +218,20 @@ func (b *bullServer) search(w http.ResponseWriter, r *http.Request) error {
 		Page          *page
 		ReadOnly      bool
 		Query         string
+		Regexp        bool
 		StaticHash    func(string) string
+
+		// server-rendered search results
+		Error      string
+		Results    []searchResult
+		NumResults int
+		PageNum    int
+		NumPages   int
+		PrevURL    string
+		NextURL    string
+		CreatePage bool
+		CreateURL  string
+		Journal    *journalNav
 	}{
 		URLPrefix:     b.root,
 		URLBullPrefix: b.URLBullPrefix(),
@@ -38,50 +241,181 @@ func (b *bullServer) search(w http.ResponseWriter, r *http.Request) error {
 			PageName: pageName,
 			FileName: page2desired(pageName),
 		},
-		Query:      r.FormValue("q"),
+		ReadOnly:   b.editor == "",
+		Query:      raw,
+		Regexp:     isRegexp,
 		StaticHash: b.staticHash,
+
+		Error:      searchErr,
+		Results:    results,
+		NumResults: numResults,
+		PageNum:    pageNum,
+		NumPages:   numPages,
+		PrevURL:    prevURL,
+		NextURL:    nextURL,
+		CreatePage: createPage,
+		CreateURL:  b.URLBullPrefix() + "edit/" + (&url.URL{Path: raw}).EscapedPath(),
+		Journal:    b.journalNav(&page{PageName: pageName}),
 	})
 }
 
-func grep(content, query string) []string {
-	queryl := strings.ToLower(query)
-	var matches []string
+// grep returns all lines of content which contain any of terms
+// (which must be lower-case).
+func grep(content string, terms []string) []matchingLine {
+	if len(terms) == 0 {
+		return nil
+	}
+	var matches []matchingLine
+	lineno := 0
 	for line := range strings.SplitSeq(content, "\n") {
-		if strings.Contains(strings.ToLower(line), queryl) {
-			matches = append(matches, line)
+		lineno++
+		linel := strings.ToLower(line)
+		for _, term := range terms {
+			if strings.Contains(linel, term) {
+				ranges := matchRanges(line, terms)
+				if ranges == nil {
+					ranges = [][2]int{} // encode as [], not null
+				}
+				matches = append(matches, matchingLine{
+					Line:   lineno,
+					Text:   line,
+					Ranges: ranges,
+				})
+				break
+			}
 		}
 	}
 	return matches
 }
 
+// grepRegexp returns all lines of content which match re. It checks ctx
+// before each line, so that a timeout also interrupts searching large pages.
+func grepRegexp(ctx context.Context, content string, re *regexp.Regexp) ([]matchingLine, error) {
+	var matches []matchingLine
+	lineno := 0
+	for line := range strings.SplitSeq(content, "\n") {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		lineno++
+		locs := re.FindAllStringIndex(line, -1)
+		if locs == nil {
+			continue
+		}
+		ranges := make([][2]int, 0, len(locs))
+		for _, loc := range locs {
+			if loc[0] == loc[1] {
+				continue // empty match, e.g. for ^
+			}
+			ranges = append(ranges, [2]int{loc[0], loc[1]})
+		}
+		matches = append(matches, matchingLine{
+			Line:   lineno,
+			Text:   line,
+			Ranges: ranges,
+		})
+	}
+	return matches, nil
+}
+
+// annotateHeadings sets the HeadingID of lines (sorted by line number)
+// to the ID of the nearest heading preceding (or on) each line.
+func annotateHeadings(doc *textDoc, lines []matchingLine) {
+	headings := doc.headings
+	hidx := -1
+	for idx := range lines {
+		for hidx+1 < len(headings) && headings[hidx+1].Line <= lines[idx].Line {
+			hidx++
+		}
+		if hidx >= 0 {
+			lines[idx].HeadingID = headings[hidx].ID
+		}
+	}
+}
+
+// parseQuery parses the q= parameter of a search request. With regexp=1,
+// q= is a regular expression.
+func parseQuery(r *http.Request) (*query.Query, error) {
+	raw := r.FormValue("q")
+	if raw == "" {
+		return nil, httpError(http.StatusBadRequest, fmt.Errorf("empty q= parameter not allowed"))
+	}
+	if len(raw) < 2 {
+		return nil, httpError(http.StatusBadRequest, fmt.Errorf("minimum query length: 2 characters"))
+	}
+	parse := query.Parse
+	if r.FormValue("regexp") == "1" {
+		parse = query.ParseRegexp
+	}
+	q, err := parse(raw)
+	if err != nil {
+		return nil, httpError(http.StatusBadRequest, err)
+	}
+	return q, nil
+}
+
 type progressUpdate struct {
 	Type    string `json:"type"`
 	Message string `json:"message"`
 }
 
 type match struct {
-	Type          string   `json:"type"`
-	PageName      string   `json:"page_name"`
-	MatchingLines []string `json:"matching_lines"`
-	score         float64
+	Type          string         `json:"type"`
+	PageName      string         `json:"page_name"`
+	MatchingLines []string       `json:"matching_lines"` // Text of Lines
+	Lines         []matchingLine `json:"lines"`
+	Score         float64        `json:"score"` // higher is more relevant
 }
 
-func (b *bullServer) internalsearch(ctx context.Context, query string, progress chan<- progressUpdate) ([]match, error) {
-	log.Printf("searching for query %q", query)
+type matchingLine struct {
+	Line int    `json:"line"` // 1-based, 0 for page name matches
+	Text string `json:"text"`
+	// Ranges are the byte offsets [start, end) of all hits in Text.
+	Ranges [][2]int `json:"ranges"`
+	// HeadingID is the ID of the nearest heading preceding (or on) the
+	// line, for linking to page#heading. Empty if there is none.
+	HeadingID string `json:"heading_id,omitempty"`
+}
 
-	i := newIndexer(b.content)
+func (b *bullServer) internalsearch(ctx context.Context, q *query.Query, progress chan<- progressUpdate) ([]match, error) {
+	log.Printf("searching for query %q", q)
+
+	// Queries are answered from the in-memory text index,
+	// which is built alongside the backlink index.
+	<-b.idxReady
+	text := b.idx.Load().text
+	if text == nil {
+		text = newTextIndex(nil)
+	}
+	termCandidates := text.termCandidates(q.Terms())
+	candidates := text.queryCandidates(q.Required(), termCandidates)
+	terms := q.Terms()
+	re := q.Regexp()
+	grepLines := func(s string) ([]matchingLine, error) { return grep(s, terms), nil }
+	if re != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, regexpSearchTimeout)
+		defer cancel()
+		grepLines = func(s string) ([]matchingLine, error) { return grepRegexp(ctx, s, re) }
+	}
+	ranker := text.newRanker(q, time.Now(), termCandidates)
 
 	var (
 		resultsMu sync.Mutex
 		results   []match
 
-		readg     errgroup.Group
+		searchg   errgroup.Group
 		progressg sync.WaitGroup
 
-		filesRead atomic.Uint64
+		pagesSearched atomic.Uint64
 	)
 	progressCtx, progressCanc := context.WithCancel(ctx)
-	defer progressCanc()
+	// Synchronize with the progress update goroutine to ensure it no longer
+	// tries to use the ResponseWriter by the time this handler returns.
+	defer func() {
+		progressCanc()
+		progressg.Wait()
+	}()
 	if progress != nil {
 		progressg.Go(func() {
 			for {
@@ -91,47 +425,71 @@ func (b *bullServer) internalsearch(ctx context.Context, query string, progress
 				case <-time.After(1 * time.Second):
 					progress <- progressUpdate{
 						Type:    "progress",
-						Message: fmt.Sprintf("searched through %d files", filesRead.Load()),
+						Message: fmt.Sprintf("searched through %d pages", pagesSearched.Load()),
 					}
 				}
 			}
 		})
 	}
+	// Content is only needed to decide whether a page matches (if the index
+	// cannot tell), or to list matching lines.
+	needContent := len(terms) > 0 || re != nil
+	type searchJob struct {
+		doc    *textDoc
+		result query.Result // see query.MatchIndexed
+	}
+	searchq := make(chan searchJob)
 	for range runtime.NumCPU() {
-		readg.Go(func() error {
-			for pg := range i.readq {
+		searchg.Go(func() error {
+			for job := range searchq {
 				if err := ctx.Err(); err != nil {
 					return err
 				}
-				// fmt.Printf("reading %s\n", pg.FileName)
-				pg, err := b.read(pg.FileName)
-				if err != nil {
-					// TODO: send an error result
-					log.Printf("read: %v", err)
+				pagesSearched.Add(1)
+				doc := job.doc
+				var content string
+				if job.result == query.Unknown || needContent {
+					// The index does not keep the content of pages in memory.
+					pg, err := b.read(doc.fileName)
+					if err != nil {
+						continue // e.g. deleted since it was indexed
+					}
+					content = pg.Content
+				}
+				// Regular expressions are matched line by line (like grep)
+				// instead of with q.Match, so that the timeout interrupts
+				// searching large pages.
+				if job.result == query.Unknown && re == nil && !q.Match(searchDoc{doc, content}) {
 					continue
 				}
-				filesRead.Add(1)
-				matches := grep(pg.Content, query)
-				nameMatches := grep(pg.PageName, query)
-				matches = append(matches, nameMatches...)
-				if len(matches) == 0 {
+				lines, err := grepLines(content)
+				if err != nil {
+					return err
+				}
+				if job.result == query.Unknown && re != nil && len(lines) == 0 {
 					continue
 				}
-				var score float64
-				if len(nameMatches) > 0 {
-					if pg.PageName == query {
-						score = 1 // exact page match
-					} else if strings.HasPrefix(pg.PageName, query) {
-						score = 0.9 // prefix match
-					} else {
-						score = 0.5 // name match
-					}
+				if len(lines) > 0 {
+					annotateHeadings(doc, lines)
+				}
+				nameLines, err := grepLines(doc.pageName)
+				if err != nil {
+					return err
+				}
+				for _, line := range nameLines {
+					line.Line = 0
+					lines = append(lines, line)
+				}
+				texts := make([]string, len(lines))
+				for idx, line := range lines {
+					texts[idx] = line.Text
 				}
 				m := match{
 					Type:          "result",
-					PageName:      pg.PageName,
-					MatchingLines: matches,
-					score:         score,
+					PageName:      doc.pageName,
+					MatchingLines: texts,
+					Lines:         lines,
+					Score:         ranker.score(doc, content),
 				}
 				resultsMu.Lock()
 				results = append(results, m)
@@ -140,25 +498,54 @@ func (b *bullServer) internalsearch(ctx context.Context, query string, progress
 			return nil
 		})
 	}
-	if err := i.walk(); err != nil {
-		return nil, err
+	// mayContain reports whether the content of doc might contain term
+	// according to the index. The candidates of negated terms, which
+	// termCandidates does not cover, are looked up on demand.
+	mayContain := func(doc *textDoc) func(term string) bool {
+		return func(term string) bool {
+			c, ok := termCandidates[term]
+			if !ok {
+				c = text.candidates(term)
+				termCandidates[term] = c
+			}
+			return c[doc.pageName]
+		}
 	}
-	if err := readg.Wait(); err != nil {
+feed:
+	for _, doc := range text.docs {
+		if candidates != nil && !candidates[doc.pageName] {
+			continue
+		}
+		// Filters (path:, tag:, has:, …) and terms not contained in the
+		// index are decided without reading the page.
+		result := q.MatchIndexed(searchDoc{textDoc: doc}, mayContain(doc))
+		if result == query.NoMatch {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			break feed
+		case searchq <- searchJob{doc: doc, result: result}:
+		}
+	}
+	close(searchq)
+	err := searchg.Wait()
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err == context.DeadlineExceeded && re != nil {
+		return nil, httpError(http.StatusServiceUnavailable, fmt.Errorf("regexp search timed out after %v", regexpSearchTimeout))
+	}
+	if err != nil {
 		return nil, err
 	}
-	// Synchronize with the progress update goroutine to ensure it no longer
-	// tries to use the ResponseWriter by the time this handler returns.
-	progressCanc()
-	progressg.Wait()
-
 	sort.SliceStable(results, func(i, j int) bool {
 		ri := results[i]
 		rj := results[j]
-		if ri.score == rj.score {
-			// both are the same kind of match
+		if ri.Score == rj.Score {
 			return ri.PageName < rj.PageName
 		}
-		return ri.score > rj.score
+		return ri.Score > rj.Score
 	})
 
 	return results, nil
@@ -170,12 +557,9 @@ func (b *bullServer) searchAPI(w http.ResponseWriter, r *http.Request) error {
 		return fmt.Errorf("BUG: ResponseWriter does not implement http.Flusher")
 	}
 
-	query := r.FormValue("q")
-	if query == "" {
-		return httpError(http.StatusBadRequest, fmt.Errorf("empty q= parameter not allowed"))
-	}
-	if len(query) < 2 {
-		return httpError(http.StatusBadRequest, fmt.Errorf("minimum query length: 2 characters"))
+	q, err := parseQuery(r)
+	if err != nil {
+		return err
 	}
 
 	ctx := r.Context()
@@ -199,11 +583,26 @@ func (b *bullServer) searchAPI(w http.ResponseWriter, r *http.Request) error {
 	}()
 
 	start := time.Now()
-	results, err := b.internalsearch(ctx, query, progress)
+	results, err := b.internalsearch(ctx, q, progress)
 	if err != nil {
-		return err
+		if err == context.Canceled {
+			return err
+		}
+		// The response has already started, so report the error
+		// as an event instead of an HTTP status code.
+		log.Printf("search for query %q failed: %v", q, err)
+		b, err := json.Marshal(progressUpdate{
+			Type:    "error",
+			Message: err.Error(),
+		})
+		if err != nil {
+			return err
+		}
+		w.Write(append(append([]byte("data: "), b...), '\n', '\n'))
+		flusher.Flush()
+		return nil
 	}
-	log.Printf("search for query %q done in %v, now streaming results", query, time.Since(start))
+	log.Printf("search for query %q done in %v, now streaming results", q, time.Since(start))
 
 	// stream search results
 	for _, result := range results {
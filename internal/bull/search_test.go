@@ -0,0 +1,350 @@
+package bull
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gokrazy/bull/internal/query"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestHighlight(t *testing.T) {
+	for _, tt := range []struct {
+		line  string
+		terms []string
+		want  template.HTML
+	}{
+		{
+			line:  "no match here",
+			terms: []string{"bull"},
+			want:  "no match here",
+		},
+		{
+			line:  "Bull <3 bulls",
+			terms: []string{"bull"},
+			want:  "<mark>Bull</mark> &lt;3 <mark>bull</mark>s",
+		},
+		{
+			line:  "overlapping terms",
+			terms: []string{"overlap", "lapping", "terms"},
+			want:  "<mark>overlapping</mark> <mark>terms</mark>",
+		},
+		{
+			line:  "no terms",
+			terms: nil,
+			want:  "no terms",
+		},
+	} {
+		t.Run(tt.line, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, highlight(tt.line, tt.terms)); diff != "" {
+				t.Errorf("highlight(%q, %q): unexpected diff (-want +got):\n%s", tt.line, tt.terms, diff)
+			}
+		})
+	}
+}
+
+func TestSearchPage(t *testing.T) {
+	files := map[string]string{
+		"exact.md": "not much here",
+	}
+	for i := range 25 {
+		files[fmt.Sprintf("page%02d.md", i)] = fmt.Sprintf("line %d mentions <exact> matches", i)
+	}
+	b := newTestBull(t, files)
+	b.editor = "textarea"
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET "+b.URLBullPrefix()+"search", handleError(b.search))
+	testsrv := httptest.NewServer(mux)
+	defer testsrv.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := testsrv.Client().Get(testsrv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	{
+		code, body := get("/_bull/search?q=matches")
+		if got, want := code, http.StatusOK; got != want {
+			t.Fatalf("unexpected HTTP status: got %v, want %v", got, want)
+		}
+		for _, want := range []string{
+			"Search results: 25 (page 1 of 2)",
+			"mentions &lt;exact&gt; <mark>matches</mark>",
+			// Which pages are listed first depends on their
+			// modification time, so do not check for a specific page.
+			`<a class="bull_lineno" href="/page`,
+			`">1</a>: line `,
+			`href="/_bull/search?page=2&amp;q=matches"`,
+			`Create page <code>matches</code>`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("response does not contain %q", want)
+			}
+		}
+		if got, want := strings.Count(body, "<li><a href=\"/page"), searchResultsPerPage; got != want {
+			t.Errorf("got %d results on page 1, want %d", got, want)
+		}
+	}
+
+	{
+		code, body := get("/_bull/search?q=matches&page=2")
+		if got, want := code, http.StatusOK; got != want {
+			t.Fatalf("unexpected HTTP status: got %v, want %v", got, want)
+		}
+		if got, want := strings.Count(body, "<li><a href=\"/page"), 5; got != want {
+			t.Errorf("got %d results on page 2, want %d", got, want)
+		}
+		if want := `href="/_bull/search?q=matches"`; !strings.Contains(body, want) {
+			t.Errorf("response does not contain %q", want)
+		}
+	}
+
+	{
+		_, body := get("/_bull/search?q=exact")
+		if strings.Contains(body, "Create page <code>exact</code>") {
+			t.Errorf("response unexpectedly offers to create existing page")
+		}
+	}
+
+	{
+		code, body := get("/_bull/search?q=" + "%28unbalanced")
+		if got, want := code, http.StatusBadRequest; got != want {
+			t.Errorf("unexpected HTTP status: got %v, want %v", got, want)
+		}
+		if want := "missing )"; !strings.Contains(body, want) {
+			t.Errorf("response does not contain %q", want)
+		}
+	}
+}
+
+func TestSearchLines(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"notes.md": strings.Join([]string{
+			"milk before any heading",
+			"# Shopping list",
+			"",
+			"- buy milk",
+			"",
+			"# Shopping list",
+			"",
+			"Milk and more milk",
+			"",
+			"```",
+			"# not a heading, milk",
+			"```",
+		}, "\n"),
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	// Headings are computed at index time, not per query.
+	wantHeadings := []heading{
+		{Line: 2, ID: "shopping-list"},
+		{Line: 6, ID: "shopping-list-1"},
+	}
+	if diff := cmp.Diff(wantHeadings, idx.text.docs["notes"].headings); diff != "" {
+		t.Errorf("unexpected headings: diff (-want +got):\n%s", diff)
+	}
+
+	q, err := query.Parse("milk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := b.internalsearch(t.Context(), q, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	want := []matchingLine{
+		{Line: 1, Text: "milk before any heading", Ranges: [][2]int{{0, 4}}},
+		{Line: 4, Text: "- buy milk", Ranges: [][2]int{{6, 10}}, HeadingID: "shopping-list"},
+		{Line: 8, Text: "Milk and more milk", Ranges: [][2]int{{0, 4}, {14, 18}}, HeadingID: "shopping-list-1"},
+		{Line: 11, Text: "# not a heading, milk", Ranges: [][2]int{{17, 21}}, HeadingID: "shopping-list-1"},
+	}
+	if diff := cmp.Diff(want, results[0].Lines); diff != "" {
+		t.Errorf("unexpected matching lines: diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestSearchFromIndex(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"projects/bull.md": "#project\n\nbull is a wiki\n",
+		"days/monday.md":   "- [ ] buy milk\n",
+		"recipes.md":       "flour, eggs, milk\n",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	// Queries made of filters (and terms which the index rules out) are
+	// answered from the index, without reading pages: removing the files
+	// does not change their results.
+	for _, fn := range []string{"projects/bull.md", "days/monday.md", "recipes.md"} {
+		if err := os.Remove(filepath.Join(b.contentDir, fn)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tt := range []struct {
+		query string
+		want  []string
+	}{
+		{query: "tag:project", want: []string{"projects/bull"}},
+		{query: "has:task", want: []string{"days/monday"}},
+		{query: "path:days/ OR path:recipes", want: []string{"days/monday", "recipes"}},
+		{query: "-eggs", want: []string{"days/monday", "projects/bull"}},
+		{query: "-tag:project -has:task", want: []string{"recipes"}},
+	} {
+		q, err := query.Parse(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := b.internalsearch(t.Context(), q, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, m := range results {
+			got = append(got, m.PageName)
+		}
+		slices.Sort(got)
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("search(%s): unexpected diff (-want +got):\n%s", tt.query, diff)
+		}
+	}
+}
+
+func TestRegexpSearch(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"todo.md":  "TODO(stapelberg): write tests\nTODO: not assigned",
+		"dates.md": "released on 2026-10-18",
+		"plain.md": "todo(lowercase)",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET "+b.URLBullPrefix()+"_search", handleError(b.searchAPI))
+	mux.Handle("GET "+b.URLBullPrefix()+"suggest", handleError(b.suggest))
+	testsrv := httptest.NewServer(mux)
+	defer testsrv.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := testsrv.Client().Get(testsrv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	{
+		_, body := get("/_bull/_search?regexp=1&q=" + url.QueryEscape(`TODO\(\w+\)`))
+		var results []match
+		for line := range strings.SplitSeq(body, "\n") {
+			data, ok := strings.CutPrefix(line, "data: ")
+			if !ok {
+				continue
+			}
+			var m match
+			if err := json.Unmarshal([]byte(data), &m); err != nil {
+				t.Fatal(err)
+			}
+			if m.Type == "result" {
+				results = append(results, m)
+			}
+		}
+		want := []match{
+			{
+				Type:          "result",
+				PageName:      "todo",
+				MatchingLines: []string{"TODO(stapelberg): write tests"},
+				Lines: []matchingLine{
+					{Line: 1, Text: "TODO(stapelberg): write tests", Ranges: [][2]int{{0, 16}}},
+				},
+			},
+		}
+		if diff := cmp.Diff(want, results, cmpopts.IgnoreFields(match{}, "Score"), cmp.AllowUnexported(match{})); diff != "" {
+			t.Errorf("unexpected results: diff (-want +got):\n%s", diff)
+		}
+	}
+
+	{
+		code, body := get("/_bull/suggest?regexp=1&q=" + url.QueryEscape(`\d{4}-\d{2}-\d{2}`))
+		if got, want := code, http.StatusOK; got != want {
+			t.Fatalf("unexpected HTTP status: got %v, want %v", got, want)
+		}
+		if want := `["\\d{4}-\\d{2}-\\d{2}",["dates"]]`; strings.TrimSpace(body) != want {
+			t.Errorf("unexpected suggestions: got %s, want %s", body, want)
+		}
+	}
+
+	{
+		code, _ := get("/_bull/_search?regexp=1&q=" + url.QueryEscape(`TODO(`))
+		if got, want := code, http.StatusBadRequest; got != want {
+			t.Errorf("unexpected HTTP status for invalid regexp: got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestGrepRegexpCanceled(t *testing.T) {
+	re := regexp.MustCompile(`milk`)
+	content := strings.Repeat("buy milk\n", 1000)
+	lines, err := grepRegexp(t.Context(), content, re)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(lines), 1000; got != want {
+		t.Errorf("grepRegexp: got %d lines, want %d", got, want)
+	}
+
+	// Matching stops within a page once the context is done
+	// (e.g. when the regexp search timeout expired).
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := grepRegexp(ctx, content, re); err != context.Canceled {
+		t.Errorf("grepRegexp with canceled context: got err %v, want %v", err, context.Canceled)
+	}
+}
@@ -28,6 +28,8 @@ type bullServer struct {
 	idx             atomic.Pointer[idx]
 	idxMu           sync.Mutex    // serializes index updates
 	idxReady        chan struct{} // closed when initial indexing completes
+	indexCacheDir   string        // if non-empty, the index is persisted here
+	migrateMu       sync.Mutex    // serializes task migrations (see migrateAPI)
 	editor          string
 	root            string
 	watch           string
@@ -0,0 +1,130 @@
+package bull
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// tagURL returns the URL of the tag browser page for tag (without #).
+func (b *bullServer) tagURL(tag string) string {
+	return b.URLBullPrefix() + "tag/" + (&page{PageName: tag}).URLPath()
+}
+
+// taggedPages returns the (sorted) names of all pages tagged with tag,
+// or with a hierarchical tag below tag (e.g. #project/bull for project).
+func (t *textIndex) taggedPages(tag string) []string {
+	var pageNames []string
+	for candidate, tagged := range t.tags {
+		if candidate == tag || strings.HasPrefix(candidate, tag+"/") {
+			pageNames = append(pageNames, tagged...)
+		}
+	}
+	slices.Sort(pageNames)
+	return slices.Compact(pageNames)
+}
+
+// subtags returns the (sorted) tags below tag, e.g. project/bull for project.
+func (t *textIndex) subtags(tag string) []string {
+	var subtags []string
+	for candidate := range t.tags {
+		if strings.HasPrefix(candidate, tag+"/") {
+			subtags = append(subtags, candidate)
+		}
+	}
+	slices.Sort(subtags)
+	return subtags
+}
+
+// tagCounts returns the number of pages per tag. Parents of hierarchical
+// tags are included even when no page uses them directly, e.g. #project/bull
+// counts towards project and project/bull.
+func (t *textIndex) tagCounts() map[string]int {
+	pages := make(map[string]map[string]bool)
+	for tag, tagged := range t.tags {
+		for {
+			if pages[tag] == nil {
+				pages[tag] = make(map[string]bool)
+			}
+			for _, pageName := range tagged {
+				pages[tag][pageName] = true
+			}
+			idx := strings.LastIndexByte(tag, '/')
+			if idx == -1 {
+				break
+			}
+			tag = tag[:idx]
+		}
+	}
+	counts := make(map[string]int, len(pages))
+	for tag, pageNames := range pages {
+		counts[tag] = len(pageNames)
+	}
+	return counts
+}
+
+func (b *bullServer) tagsContent() []byte {
+	<-b.idxReady
+	text := b.idx.Load().text
+	if text == nil {
+		text = newTextIndex(nil)
+	}
+	counts := text.tagCounts()
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# tags\n\n")
+	if len(counts) == 0 {
+		fmt.Fprintf(&buf, "No pages contain hashtags (like `#project`) yet.\n")
+		return buf.Bytes()
+	}
+	// Escape # in link texts, otherwise the hashtag extension would render
+	// a (search) link within the tag link.
+	fmt.Fprintf(&buf, "| tag | pages |\n")
+	fmt.Fprintf(&buf, "|-----|------:|\n")
+	for _, tag := range slices.Sorted(maps.Keys(counts)) {
+		fmt.Fprintf(&buf, "| [\\#%s](%s) | %d |\n", tableCell(tag), b.tagURL(tag), counts[tag])
+	}
+	return buf.Bytes()
+}
+
+func (b *bullServer) tagContent(tag string) []byte {
+	<-b.idxReady
+	text := b.idx.Load().text
+	if text == nil {
+		text = newTextIndex(nil)
+	}
+	pageNames := text.taggedPages(tag)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# tag: #%s\n\n", tag)
+	if idx := strings.LastIndexByte(tag, '/'); idx > -1 {
+		parent := tag[:idx]
+		fmt.Fprintf(&buf, "parent: [\\#%s](%s)\n\n", parent, b.tagURL(parent))
+	}
+	if subtags := text.subtags(tag); len(subtags) > 0 {
+		links := make([]string, len(subtags))
+		for idx, subtag := range subtags {
+			links[idx] = fmt.Sprintf("[\\#%s](%s)", subtag, b.tagURL(subtag))
+		}
+		fmt.Fprintf(&buf, "subtags: %s\n\n", strings.Join(links, " • "))
+	}
+	fmt.Fprintf(&buf, "%d tagged pages ([all tags](%stags))\n\n", len(pageNames), b.URLBullPrefix())
+	writeRecentTable(&buf, text, pageNames)
+	return buf.Bytes()
+}
+
+func (b *bullServer) tags(w http.ResponseWriter, r *http.Request) error {
+	return b.renderBullMarkdown(w, r, "tags", bytes.NewBuffer(b.tagsContent()))
+}
+
+func (b *bullServer) tag(w http.ResponseWriter, r *http.Request) error {
+	tag := strings.ToLower(strings.Trim(r.PathValue("tag"), "/"))
+	if tag == "" {
+		http.Redirect(w, r, b.URLBullPrefix()+"tags", http.StatusFound)
+		return nil
+	}
+	return b.renderBullMarkdown(w, r, "tag/"+tag, bytes.NewBuffer(b.tagContent(tag)))
+}
@@ -0,0 +1,119 @@
+package bull
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTags(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"index.md":         "welcome #Home",
+		"projects/bull.md": "a wiki #project/bull #project/bull #todo",
+		"projects/gok.md":  "router #project/gokrazy",
+		"notes/code.md":    "```\n#notatag\n```\nalso `#notatag` inline",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	wantTags := map[string][]string{
+		"home":            {"index"},
+		"project/bull":    {"projects/bull"},
+		"project/gokrazy": {"projects/gok"},
+		"todo":            {"projects/bull"},
+	}
+	if diff := cmp.Diff(wantTags, idx.text.tags); diff != "" {
+		t.Errorf("unexpected tag index: diff (-want +got):\n%s", diff)
+	}
+
+	wantCounts := map[string]int{
+		"home":            1,
+		"project":         2,
+		"project/bull":    1,
+		"project/gokrazy": 1,
+		"todo":            1,
+	}
+	if diff := cmp.Diff(wantCounts, idx.text.tagCounts()); diff != "" {
+		t.Errorf("unexpected tag counts: diff (-want +got):\n%s", diff)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET "+b.URLBullPrefix()+"tags", handleError(b.tags))
+	mux.Handle("GET "+b.URLBullPrefix()+"tag/{tag...}", handleError(b.tag))
+	testsrv := httptest.NewServer(mux)
+	defer testsrv.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest("GET", testsrv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "text/markdown")
+		resp, err := testsrv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	{
+		code, body := get("/_bull/tags")
+		if got, want := code, http.StatusOK; got != want {
+			t.Fatalf("unexpected HTTP status: got %v, want %v", got, want)
+		}
+		for _, want := range []string{
+			`<a href="/_bull/tag/project">#project</a>`,
+			`<a href="/_bull/tag/project/bull">#project/bull</a>`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("response does not contain %q", want)
+			}
+		}
+		if strings.Contains(body, "notatag") {
+			t.Errorf("response unexpectedly contains hashtag from code")
+		}
+	}
+
+	{
+		code, body := get("/_bull/tag/Project")
+		if got, want := code, http.StatusOK; got != want {
+			t.Fatalf("unexpected HTTP status: got %v, want %v", got, want)
+		}
+		for _, want := range []string{
+			"2 tagged pages",
+			`<a href="/_bull/tag/project/gokrazy">#project/gokrazy</a>`,
+			`<a href="/projects/bull">projects/bull</a>`,
+			`<a href="/projects/gok">projects/gok</a>`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("response does not contain %q", want)
+			}
+		}
+	}
+
+	{
+		code, body := get("/_bull/tag/project/bull")
+		if got, want := code, http.StatusOK; got != want {
+			t.Fatalf("unexpected HTTP status: got %v, want %v", got, want)
+		}
+		if !strings.Contains(body, "1 tagged pages") {
+			t.Errorf("response does not contain %q", "1 tagged pages")
+		}
+		if strings.Contains(body, `href="/projects/gok"`) {
+			t.Errorf("response unexpectedly contains sibling tag page projects/gok")
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package bull
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"html/template"
+	"maps"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gokrazy/bull/internal/itasklist"
+)
+
+// taskRegexp matches task list items, capturing the task state (see
+// itasklist.StateNames) and the text of the task.
+var taskRegexp = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+\[([\sxX/><-])\]\s*(.*)$`)
+
+// dueRegexp matches the due date of a task, either in the notation of the
+// Obsidian Tasks plugin (📅 2026-10-20) or as due:2026-10-20.
+var dueRegexp = regexp.MustCompile(`(?:📅\s*|\bdue:\s*)(\d{4}-\d{2}-\d{2})`)
+
+// task is a task list item of a page, as extracted by the indexer.
+type task struct {
+	pageName string
+	line     int       // 1-based
+	state    byte      // see itasklist.StateNames
+	text     string    // without list marker and checkbox
+	due      time.Time // zero if the task has no due date
+}
+
+// parseTasks returns the tasks of page pageName with content, skipping
+// front matter and fenced code blocks.
+func parseTasks(pageName, content string) []task {
+	var tasks []task
+	for lineno, line := range proseLines(content) {
+		m := taskRegexp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		t := task{
+			pageName: pageName,
+			line:     lineno,
+			state:    m[1][0],
+			text:     strings.TrimSpace(m[2]),
+		}
+		if itasklist.StateNames[t.state] == "" {
+			t.state = ' ' // e.g. [\t]
+		}
+		if d := dueRegexp.FindStringSubmatch(t.text); d != nil {
+			// Invalid dates like 2026-13-01 result in no due date.
+			t.due, _ = time.ParseInLocation(time.DateOnly, d[1], time.Local)
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks
+}
+
+// taskFilter selects and groups the tasks of the task dashboard. Its fields
+// correspond to URL parameters of /_bull/tasks.
+type taskFilter struct {
+	group  string   // page (default) or due
+	states []string // state names (see itasklist.StateNames)
+	path   string   // page name prefix
+	due    string   // overdue, today (or earlier), week (or earlier), any, none or empty
+	q      string   // case-insensitive substring of the task text
+}
+
+var defaultTaskStates = []string{"open", "inprogress"}
+
+// parseTaskFilter returns the taskFilter requested via URL parameters.
+func parseTaskFilter(r *http.Request) (taskFilter, error) {
+	f := taskFilter{
+		group:  cmp.Or(r.FormValue("group"), "page"),
+		states: defaultTaskStates,
+		path:   r.FormValue("path"),
+		due:    r.FormValue("due"),
+		q:      r.FormValue("q"),
+	}
+	if f.group != "page" && f.group != "due" {
+		return f, httpError(http.StatusBadRequest, fmt.Errorf("invalid group %q (valid: page, due)", f.group))
+	}
+	switch f.due {
+	case "", "overdue", "today", "week", "any", "none":
+	default:
+		return f, httpError(http.StatusBadRequest, fmt.Errorf("invalid due %q (valid: overdue, today, week, any, none)", f.due))
+	}
+	if state := r.FormValue("state"); state == "all" {
+		f.states = nil
+	} else if state != "" {
+		valid := slices.Collect(maps.Values(itasklist.StateNames))
+		f.states = strings.Split(state, ",")
+		for _, name := range f.states {
+			if !slices.Contains(valid, name) {
+				return f, httpError(http.StatusBadRequest, fmt.Errorf("invalid state %q", name))
+			}
+		}
+	}
+	return f, nil
+}
+
+// url returns the URL of the task dashboard with filter f.
+func (f taskFilter) url(b *bullServer) string {
+	v := make(url.Values)
+	if f.group != "page" {
+		v.Set("group", f.group)
+	}
+	if f.states == nil {
+		v.Set("state", "all")
+	} else if !slices.Equal(f.states, defaultTaskStates) {
+		v.Set("state", strings.Join(f.states, ","))
+	}
+	for key, value := range map[string]string{"path": f.path, "due": f.due, "q": f.q} {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	u := b.URLBullPrefix() + "tasks"
+	if len(v) > 0 {
+		u += "?" + v.Encode()
+	}
+	return u
+}
+
+// matches returns whether filter f selects t, with today being the start
+// of the current day.
+func (f taskFilter) matches(t task, today time.Time) bool {
+	if f.states != nil && !slices.Contains(f.states, itasklist.StateNames[t.state]) {
+		return false
+	}
+	if !strings.HasPrefix(t.pageName, f.path) {
+		return false
+	}
+	if f.q != "" && !strings.Contains(strings.ToLower(t.text), strings.ToLower(f.q)) {
+		return false
+	}
+	switch f.due {
+	case "overdue":
+		return !t.due.IsZero() && t.due.Before(today)
+	case "today":
+		return !t.due.IsZero() && !t.due.After(today)
+	case "week":
+		return !t.due.IsZero() && t.due.Before(today.AddDate(0, 0, 7))
+	case "any":
+		return !t.due.IsZero()
+	case "none":
+		return t.due.IsZero()
+	}
+	return true
+}
+
+// tasks returns the tasks of all pages which f selects, sorted by page
+// name and line (or by due date first when grouping by due date).
+func (b *bullServer) tasks(idx *idx, f taskFilter, today time.Time) []task {
+	if idx.text == nil {
+		return nil
+	}
+	var tasks []task
+	for _, doc := range idx.text.docs {
+		for _, t := range doc.tasks {
+			if f.matches(t, today) {
+				tasks = append(tasks, t)
+			}
+		}
+	}
+	slices.SortFunc(tasks, func(x, y task) int {
+		if f.group == "due" {
+			// Tasks without due date go last.
+			switch {
+			case x.due.IsZero() && !y.due.IsZero():
+				return 1
+			case !x.due.IsZero() && y.due.IsZero():
+				return -1
+			}
+			if c := x.due.Compare(y.due); c != 0 {
+				return c
+			}
+		}
+		return cmp.Or(
+			strings.Compare(x.pageName, y.pageName),
+			cmp.Compare(x.line, y.line))
+	})
+	return tasks
+}
+
+// taskGroup returns the heading of the group of t.
+func taskGroup(f taskFilter, t task, today time.Time) string {
+	if f.group == "page" {
+		return "[[" + t.pageName + "]]"
+	}
+	switch {
+	case t.due.IsZero():
+		return "no due date"
+	case t.due.Before(today):
+		return t.due.Format(time.DateOnly) + " (overdue)"
+	case t.due.Equal(today):
+		return t.due.Format(time.DateOnly) + " (today)"
+	}
+	return t.due.Format(time.DateOnly)
+}
+
+// taskHTML renders t as an HTML list item, whose checkbox toggles the task
+// on its page via the _itasklist API (see itasklistAPI), which then returns
+// to the task dashboard at returnURL.
+func (b *bullServer) taskHTML(f taskFilter, t task, today time.Time, returnURL string) string {
+	var buf bytes.Buffer
+	if !t.due.IsZero() && t.due.Before(today) {
+		buf.WriteString(`<li class="bull_task_overdue">`)
+	} else {
+		buf.WriteString(`<li>`)
+	}
+	pg := &page{PageName: t.pageName}
+	if b.contentSettings.InteractiveTaskList && b.editor != "" {
+		fmt.Fprintf(&buf, `<form class="itasklist" action="%s_itasklist/%s" method="POST">`,
+			b.URLBullPrefix(),
+			pg.URLPath())
+		fmt.Fprintf(&buf, `<input type="hidden" name="return" value="%s">`, template.HTMLEscapeString(returnURL))
+		buf.WriteString(itasklist.Checkbox(t.state, t.line, false))
+		buf.WriteString(`</form>`)
+	} else {
+		buf.WriteString(itasklist.Checkbox(t.state, t.line, true))
+	}
+	buf.WriteString(template.HTMLEscapeString(t.text))
+	if f.group != "page" {
+		fmt.Fprintf(&buf, ` — <a href="%s%s">%s</a>`,
+			b.root,
+			pg.URLPath(),
+			template.HTMLEscapeString(t.pageName))
+	}
+	buf.WriteString("</li>\n")
+	return buf.String()
+}
+
+func (b *bullServer) tasksContent(f taskFilter) []byte {
+	<-b.idxReady
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	tasks := b.tasks(b.idx.Load(), f, today)
+
+	pages := make(map[string]bool)
+	for _, t := range tasks {
+		pages[t.pageName] = true
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# tasks\n\n")
+	fmt.Fprintf(&buf, "%s on %s\n\n",
+		pluralize(len(tasks), "task", "tasks"),
+		pluralize(len(pages), "page", "pages"))
+
+	link := func(label string, modify func(*taskFilter)) string {
+		g := f
+		modify(&g)
+		if g.url(b) == f.url(b) {
+			return "**" + label + "**"
+		}
+		return "[" + label + "](" + g.url(b) + ")"
+	}
+	fmt.Fprintf(&buf, "group by %s • %s\n\n",
+		link("page", func(g *taskFilter) { g.group = "page" }),
+		link("due date", func(g *taskFilter) { g.group = "due" }))
+	fmt.Fprintf(&buf, "show %s • %s • %s\n\n",
+		link("open", func(g *taskFilter) { g.states = defaultTaskStates }),
+		link("done", func(g *taskFilter) { g.states = []string{"done"} }),
+		link("all", func(g *taskFilter) { g.states = nil }))
+	fmt.Fprintf(&buf, "due %s • %s • %s • %s • %s\n\n",
+		link("any time", func(g *taskFilter) { g.due = "" }),
+		link("overdue", func(g *taskFilter) { g.due = "overdue" }),
+		link("today", func(g *taskFilter) { g.due = "today" }),
+		link("this week", func(g *taskFilter) { g.due = "week" }),
+		link("without due date", func(g *taskFilter) { g.due = "none" }))
+
+	if len(tasks) == 0 {
+		fmt.Fprintf(&buf, "No tasks match. Tasks are list items like `- [ ] buy milk 📅 2026-10-20` (or `due:2026-10-20`).\n")
+		return buf.Bytes()
+	}
+	returnURL := f.url(b)
+	var group string
+	for i, t := range tasks {
+		if g := taskGroup(f, t, today); i == 0 || g != group {
+			if i > 0 {
+				buf.WriteString("</ul>\n\n")
+			}
+			group = g
+			fmt.Fprintf(&buf, "## %s\n\n", group)
+			buf.WriteString(`<ul class="bull_tasks">` + "\n")
+		}
+		buf.WriteString(b.taskHTML(f, t, today, returnURL))
+	}
+	buf.WriteString("</ul>\n")
+	return buf.Bytes()
+}
+
+func (b *bullServer) tasksView(w http.ResponseWriter, r *http.Request) error {
+	f, err := parseTaskFilter(r)
+	if err != nil {
+		return err
+	}
+	return b.renderBullMarkdown(w, r, "tasks", bytes.NewBuffer(b.tasksContent(f)))
+}
@@ -0,0 +1,205 @@
+package bull
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseTasks(t *testing.T) {
+	content := "---\ntitle: tasks\n---\n" +
+		"- [ ] buy milk 📅 2026-10-20\n" +
+		"  * [/] call plumber due:2026-10-19\n" +
+		"```\n- [ ] in code\n```\n" +
+		"1. [x] done\n" +
+		"- [>] migrated due:2026-13-01\n" +
+		"text [ ] in a line\n"
+	day := func(s string) time.Time {
+		t.Helper()
+		d, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	want := []task{
+		{pageName: "p", line: 4, state: ' ', text: "buy milk 📅 2026-10-20", due: day("2026-10-20")},
+		{pageName: "p", line: 5, state: '/', text: "call plumber due:2026-10-19", due: day("2026-10-19")},
+		{pageName: "p", line: 9, state: 'x', text: "done"},
+		{pageName: "p", line: 10, state: '>', text: "migrated due:2026-13-01"},
+	}
+	got := parseTasks("p", content)
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(task{})); diff != "" {
+		t.Errorf("parseTasks: unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestTasks(t *testing.T) {
+	now := time.Now()
+	date := func(offset int) string {
+		return now.AddDate(0, 0, offset).Format(time.DateOnly)
+	}
+	b := newTestBull(t, map[string]string{
+		"index.md":         "- [ ] water plants\n- [x] buy milk\n",
+		"projects/bull.md": "- [ ] write docs 📅 " + date(3) + "\n- [/] fix bug due:" + date(-1) + "\n- [-] rewrite in rust\n",
+		"notes.md":         "no tasks [ ] here",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	texts := func(tasks []task) []string {
+		var texts []string
+		for _, t := range tasks {
+			texts = append(texts, t.text)
+		}
+		return texts
+	}
+	for _, tt := range []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"water plants", "write docs 📅 " + date(3), "fix bug due:" + date(-1)}},
+		{"group=due", []string{"fix bug due:" + date(-1), "write docs 📅 " + date(3), "water plants"}},
+		{"state=done,cancelled", []string{"buy milk", "rewrite in rust"}},
+		{"state=all&path=projects/", []string{"write docs 📅 " + date(3), "fix bug due:" + date(-1), "rewrite in rust"}},
+		{"due=overdue", []string{"fix bug due:" + date(-1)}},
+		{"due=week", []string{"write docs 📅 " + date(3), "fix bug due:" + date(-1)}},
+		{"due=none", []string{"water plants"}},
+		{"q=DOCS", []string{"write docs 📅 " + date(3)}},
+	} {
+		r := httptest.NewRequest("GET", "/_bull/tasks?"+tt.query, nil)
+		f, err := parseTaskFilter(r)
+		if err != nil {
+			t.Fatalf("parseTaskFilter(%q): %v", tt.query, err)
+		}
+		got := texts(b.tasks(b.idx.Load(), f, today))
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("tasks(%q): unexpected diff (-want +got):\n%s", tt.query, diff)
+		}
+		if tt.query != "" {
+			// url round-trips the filter.
+			u, err := url.Parse(f.url(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := u.Query(), r.URL.Query(); !cmp.Equal(got, want) {
+				t.Errorf("taskFilter(%q).url() = %q", tt.query, f.url(b))
+			}
+		}
+	}
+
+	for _, query := range []string{"group=tag", "state=bogus", "due=tomorrow"} {
+		r := httptest.NewRequest("GET", "/_bull/tasks?"+query, nil)
+		if _, err := parseTaskFilter(r); err == nil {
+			t.Errorf("parseTaskFilter(%q) unexpectedly succeeded", query)
+		}
+	}
+}
+
+func TestTasksToggle(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"projects/bull.md": "# bull\n\n- [ ] write docs\n- [ ] fix bug\n",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+	b.editor = "textarea"
+
+	md := string(b.tasksContent(taskFilter{group: "due", states: defaultTaskStates}))
+	for _, want := range []string{
+		"## no due date\n\n",
+		`<li><form class="itasklist" action="/_bull/_itasklist/projects/bull" method="POST">` +
+			`<input type="hidden" name="return" value="/_bull/tasks?group=due">` +
+			`<input type="checkbox" class="itask_open" title="open" data-state=" " data-line="4"></form>` +
+			`fix bug — <a href="/projects/bull">projects/bull</a></li>`,
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("tasks page does not contain %q:\n%s", want, md)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /_bull/_itasklist/{page...}", handleError(b.itasklistAPI))
+	testsrv := httptest.NewServer(mux)
+	defer testsrv.Close()
+	client := testsrv.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	post := func(ret string) *http.Response {
+		t.Helper()
+		resp, err := client.PostForm(testsrv.URL+"/_bull/_itasklist/projects/bull", url.Values{
+			"checkbox-line": {"4"},
+			"return":        {ret},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := post("/_bull/tasks?group=due")
+	if got, want := resp.StatusCode, http.StatusFound; got != want {
+		t.Fatalf("unexpected HTTP status: got %v, want %v", got, want)
+	}
+	if got, want := resp.Header.Get("Location"), "/_bull/tasks?group=due"; got != want {
+		t.Errorf("unexpected redirect: got %q, want %q", got, want)
+	}
+	pg, err := b.readFirst(page2files("projects/bull"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pg.DiskContent, "# bull\n\n- [ ] write docs\n- [x] fix bug\n"; got != want {
+		t.Errorf("after toggle: got %q, want %q", got, want)
+	}
+	// The index is updated, so the dashboard no longer lists the task.
+	if md := string(b.tasksContent(taskFilter{group: "due", states: defaultTaskStates})); strings.Contains(md, "fix bug") {
+		t.Errorf("tasks page still lists the done task:\n%s", md)
+	}
+
+	for _, line := range []string{"0", "-1", "99"} {
+		resp, err := client.PostForm(testsrv.URL+"/_bull/_itasklist/projects/bull", url.Values{
+			"checkbox-line": {line},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got, want := resp.StatusCode, http.StatusBadRequest; got != want {
+			t.Errorf("checkbox-line=%s: unexpected HTTP status: got %v, want %v", line, got, want)
+		}
+	}
+
+	for _, ret := range []string{
+		"https://example.com/",
+		"//example.com/",
+		`/\example.com`,
+		`/_bull/\..\\example.com`,
+		"/projects/bull",
+		"javascript:alert(1)",
+	} {
+		if got, want := post(ret).StatusCode, http.StatusBadRequest; got != want {
+			t.Errorf("return=%q: unexpected HTTP status: got %v, want %v", ret, got, want)
+		}
+	}
+	// Rejected requests do not toggle the task.
+	pg, err = b.readFirst(page2files("projects/bull"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pg.DiskContent, "# bull\n\n- [ ] write docs\n- [x] fix bug\n"; got != want {
+		t.Errorf("after rejected toggles: got %q, want %q", got, want)
+	}
+}
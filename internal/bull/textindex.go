@@ -0,0 +1,452 @@
+package bull
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gokrazy/bull/internal/frontmatter"
+	"github.com/gokrazy/bull/internal/query"
+)
+
+// textDoc is the searchable representation of a page. It does not contain
+// the content of the page, which searches read from disk (see searchDoc).
+type textDoc struct {
+	pageName string
+	fileName string
+	modTime  time.Time
+	empty    bool     // no content apart from front matter and whitespace
+	tokens   []string // case-folded words, sorted and deduplicated
+	length   int      // number of words (including duplicates)
+	tags     []string // case-folded hashtags (without #), sorted and deduplicated
+	hasTask  bool
+	tasks    []task
+	meta     *frontmatter.FrontMatter // nil if none
+	// contexts maps link targets to the text surrounding the links,
+	// see pageRefs.
+	contexts map[string][]string
+	// fragments and anchors are the section links and sections of the
+	// page, see pageRefs.
+	fragments map[string][]string
+	anchors   []string
+	headings  []heading
+	wikilinks []string
+	// unresolved are the references as written, if they differ from the
+	// resolved references above (see pageRefs).
+	unresolved *pageRefs
+}
+
+// newTextDoc returns the searchable representation of pg, whose
+// references (as returned by pageRefs) are refs, which can be nil.
+func newTextDoc(pg *page, refs *pageRefs) *textDoc {
+	words := words(pg.Content)
+	doc := &textDoc{
+		pageName: pg.PageName,
+		fileName: pg.FileName,
+		modTime:  pg.ModTime,
+		empty:    strings.TrimSpace(frontmatter.Blank(pg.Content)) == "",
+		length:   len(words),
+		tokens:   dedupWords(words),
+		hasTask:  taskItemRegexp.MatchString(pg.Content),
+		tasks:    parseTasks(pg.PageName, pg.Content),
+		meta:     pg.Meta,
+	}
+	if refs != nil {
+		doc.tags = refs.tags
+		doc.contexts = refs.contexts
+		doc.fragments = refs.fragments
+		doc.anchors = refs.anchors
+		doc.headings = refs.headings
+		doc.wikilinks = refs.wikilinks
+		doc.unresolved = refs.unresolved
+	}
+	return doc
+}
+
+// taskItemRegexp matches list items that start with a checkbox.
+var taskItemRegexp = regexp.MustCompile(`(?m)^\s*(?:[-*+]|\d+[.)])\s+\[[\sxX/><-]\]`)
+
+// searchDoc is a page as seen by search queries: its textDoc and its
+// content, as read from disk when searching.
+type searchDoc struct {
+	*textDoc
+	content string
+}
+
+// searchDoc implements query.Doc so that search queries can be evaluated.
+var _ query.Doc = searchDoc{}
+
+func (d searchDoc) Text() string { return d.content }
+
+func (d *textDoc) Name() string        { return d.pageName }
+func (d *textDoc) Modified() time.Time { return d.modTime }
+
+func (d *textDoc) HasTag(tag string) bool {
+	for _, t := range d.tags {
+		if t == tag || strings.HasPrefix(t, tag+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *textDoc) Meta(key string) (string, bool) { return d.meta.Field(key) }
+
+// aliases returns the aliases defined in the front matter of d.
+func (d *textDoc) aliases() []string {
+	if d.meta == nil {
+		return nil
+	}
+	return d.meta.Aliases
+}
+
+func (d *textDoc) Has(feature string) bool {
+	switch feature {
+	case "task":
+		return d.hasTask
+	}
+	return false
+}
+
+func isTokenRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// words splits s into case-folded words (runs of Unicode letters and digits).
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !isTokenRune(r)
+	})
+}
+
+// dedupWords sorts and deduplicates words (modifying the slice).
+func dedupWords(words []string) []string {
+	slices.Sort(words)
+	tokens := slices.Clip(slices.Compact(words))
+	for idx, token := range tokens {
+		// Do not keep the lower-cased copy of the entire content alive.
+		tokens[idx] = strings.Clone(token)
+	}
+	return tokens
+}
+
+// tokenize splits s into case-folded words (runs of Unicode letters and
+// digits) and returns them sorted and deduplicated.
+func tokenize(s string) []string {
+	return dedupWords(words(s))
+}
+
+// textIndex is an inverted index over the content of all pages. Like idx, a
+// textIndex is never modified after it was published: updates create a new
+// textIndex that shares all unmodified parts with the old one.
+type textIndex struct {
+	// docs maps from page name to the page's searchable content.
+	docs map[string]*textDoc
+	// postings maps from token to the (sorted) names of pages containing it.
+	postings map[string][]string
+	// grams maps from the trigrams of all tokens (and from tokens shorter
+	// than three bytes) to the (sorted) tokens containing them, which
+	// allows finding the tokens containing a word without scanning all
+	// tokens (see tokensContaining).
+	grams map[string][]string
+	// tags maps from tag to the (sorted) names of pages tagged with it.
+	tags map[string][]string
+	// aliases maps from alias to the page whose front matter defines it.
+	aliases map[string]string
+	// totalLength is the sum of the length of all docs (for ranking).
+	totalLength int
+}
+
+func newTextIndex(docs map[string]*textDoc) *textIndex {
+	postings := make(map[string][]string)
+	tags := make(map[string][]string)
+	var totalLength int
+	for pageName, doc := range docs {
+		for _, token := range doc.tokens {
+			postings[token] = append(postings[token], pageName)
+		}
+		for _, tag := range doc.tags {
+			tags[tag] = append(tags[tag], pageName)
+		}
+		totalLength += doc.length
+	}
+	for _, pageNames := range postings {
+		slices.Sort(pageNames)
+	}
+	for _, pageNames := range tags {
+		slices.Sort(pageNames)
+	}
+	grams := make(map[string][]string)
+	for token := range postings {
+		for _, gram := range trigrams(token) {
+			grams[gram] = append(grams[gram], token)
+		}
+	}
+	for _, tokens := range grams {
+		slices.Sort(tokens)
+	}
+	return &textIndex{
+		docs:        docs,
+		postings:    postings,
+		grams:       grams,
+		tags:        tags,
+		aliases:     frontMatterAliases(docs),
+		totalLength: totalLength,
+	}
+}
+
+// frontMatterAliases returns the aliases defined in the front matter of docs.
+// If multiple pages define the same alias, the first page name wins.
+func frontMatterAliases(docs map[string]*textDoc) map[string]string {
+	aliases := make(map[string]string)
+	for pageName, doc := range docs {
+		for _, alias := range doc.aliases() {
+			if other, ok := aliases[alias]; ok && other < pageName {
+				continue
+			}
+			aliases[alias] = pageName
+		}
+	}
+	return aliases
+}
+
+// apply returns a new textIndex with the pages in removals removed and the
+// pages in updates added or replaced.
+func (t *textIndex) apply(removals []string, updates []*textDoc) *textIndex {
+	newDocs := make(map[string]*textDoc, len(t.docs)+len(updates))
+	maps.Copy(newDocs, t.docs)
+	// Shallow clone: the []string value slices are shared with t.postings.
+	// This is safe because removeFromSorted/insertIntoSorted never mutate
+	// slices in place.
+	newPostings := maps.Clone(t.postings)
+	newTags := maps.Clone(t.tags)
+	totalLength := t.totalLength
+	// aliases are rarely modified, so only re-compute them when needed
+	aliasesChanged := false
+	// tokens whose postings were modified, which might have been added to
+	// or removed from the vocabulary (see grams)
+	var patched []string
+
+	for _, pageName := range removals {
+		old, ok := newDocs[pageName]
+		if !ok {
+			continue
+		}
+		delete(newDocs, pageName)
+		aliasesChanged = aliasesChanged || len(old.aliases()) > 0
+		patchBacklinks(newPostings, pageName, nil, old.tokens)
+		patched = append(patched, old.tokens...)
+		patchBacklinks(newTags, pageName, nil, old.tags)
+		totalLength -= old.length
+	}
+
+	for _, doc := range updates {
+		var oldTokens, oldTags []string
+		if old, ok := newDocs[doc.pageName]; ok {
+			oldTokens = old.tokens
+			oldTags = old.tags
+			totalLength -= old.length
+			aliasesChanged = aliasesChanged || !slices.Equal(old.aliases(), doc.aliases())
+		} else {
+			aliasesChanged = aliasesChanged || len(doc.aliases()) > 0
+		}
+		newDocs[doc.pageName] = doc
+		added, removed := diffSorted(oldTokens, doc.tokens)
+		patchBacklinks(newPostings, doc.pageName, added, removed)
+		patched = append(patched, added...)
+		patched = append(patched, removed...)
+		added, removed = diffSorted(oldTags, doc.tags)
+		patchBacklinks(newTags, doc.pageName, added, removed)
+		totalLength += doc.length
+	}
+
+	aliases := t.aliases
+	if aliasesChanged {
+		aliases = frontMatterAliases(newDocs)
+	}
+
+	// Like aliases, the vocabulary changes less often than postings.
+	newGrams := t.grams
+	gramsCloned := false
+	for _, token := range patched {
+		_, before := t.postings[token]
+		_, after := newPostings[token]
+		if before == after {
+			continue
+		}
+		if !gramsCloned {
+			newGrams = maps.Clone(t.grams)
+			gramsCloned = true
+		}
+		if after {
+			patchBacklinks(newGrams, token, trigrams(token), nil)
+		} else {
+			patchBacklinks(newGrams, token, nil, trigrams(token))
+		}
+	}
+
+	return &textIndex{
+		docs:        newDocs,
+		postings:    newPostings,
+		grams:       newGrams,
+		tags:        newTags,
+		aliases:     aliases,
+		totalLength: totalLength,
+	}
+}
+
+// trigrams returns the (deduplicated) substrings of token which are three
+// bytes long, or token itself if it is shorter.
+func trigrams(token string) []string {
+	if len(token) < 3 {
+		return []string{token}
+	}
+	grams := make([]string, 0, len(token)-2)
+	for i := 0; i+3 <= len(token); i++ {
+		grams = append(grams, token[i:i+3])
+	}
+	slices.Sort(grams)
+	return slices.Compact(grams)
+}
+
+// tokensContaining returns the tokens of all pages which contain word.
+func (t *textIndex) tokensContaining(word string) []string {
+	if len(word) < 3 {
+		// Every token containing word contains it in one of its trigrams
+		// (or is shorter than a trigram), and there are far fewer
+		// trigrams than tokens.
+		var tokens []string
+		for gram, gramTokens := range t.grams {
+			if strings.Contains(gram, word) {
+				tokens = append(tokens, gramTokens...)
+			}
+		}
+		slices.Sort(tokens)
+		return slices.Compact(tokens)
+	}
+	// Every token containing word contains all trigrams of word,
+	// so it suffices to check the tokens of the rarest trigram.
+	var rarest []string
+	for idx, gram := range trigrams(word) {
+		tokens := t.grams[gram]
+		if idx == 0 || len(tokens) < len(rarest) {
+			rarest = tokens
+		}
+	}
+	var tokens []string
+	for _, token := range rarest {
+		if strings.Contains(token, word) {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// candidates returns the names of all pages whose content might contain
+// query (case-insensitively). Each word of the query must be contained in
+// one of the page’s words, so the result is a superset of the pages for
+// which grep finds a match.
+func (t *textIndex) candidates(query string) map[string]bool {
+	qtokens := tokenize(query)
+	if len(qtokens) == 0 {
+		// The query consists only of punctuation or whitespace,
+		// so the index cannot narrow down the search.
+		result := make(map[string]bool, len(t.docs))
+		for pageName := range t.docs {
+			result[pageName] = true
+		}
+		return result
+	}
+	var result map[string]bool
+	for _, qt := range qtokens {
+		matched := make(map[string]bool)
+		for _, token := range t.tokensContaining(qt) {
+			for _, pageName := range t.postings[token] {
+				if result == nil || result[pageName] {
+					matched[pageName] = true
+				}
+			}
+		}
+		result = matched
+		if len(result) == 0 {
+			break
+		}
+	}
+	return result
+}
+
+// termCandidates returns the candidates (see candidates) of each of terms,
+// so that queryCandidates and newRanker can share them.
+func (t *textIndex) termCandidates(terms []string) map[string]map[string]bool {
+	result := make(map[string]map[string]bool, len(terms))
+	for _, term := range terms {
+		if _, ok := result[term]; !ok {
+			result[term] = t.candidates(term)
+		}
+	}
+	return result
+}
+
+// queryCandidates returns the names of all pages that might match a query
+// which requires all of terms (in the page name or content), given the
+// candidates of each term (see termCandidates). A nil result means the
+// index cannot narrow down the search.
+func (t *textIndex) queryCandidates(terms []string, termCandidates map[string]map[string]bool) map[string]bool {
+	var result map[string]bool
+	for _, term := range terms {
+		if len(tokenize(term)) == 0 {
+			continue // cannot narrow down
+		}
+		matched, ok := termCandidates[term]
+		if !ok {
+			matched = t.candidates(term)
+		}
+		matched = maps.Clone(matched) // owned by termCandidates
+		for pageName := range t.docs {
+			if strings.Contains(strings.ToLower(pageName), term) {
+				matched[pageName] = true
+			}
+		}
+		if result == nil {
+			result = matched
+			continue
+		}
+		for pageName := range result {
+			if !matched[pageName] {
+				delete(result, pageName)
+			}
+		}
+	}
+	return result
+}
+
+// updateTextIndexLocked updates the searchable content of the given pages.
+// Caller must hold b.idxMu.
+func (b *bullServer) updateTextIndexLocked(removals []string, updates []*textDoc) {
+	old := b.idx.Load()
+	text := old.text
+	if text == nil {
+		text = newTextIndex(nil)
+	}
+	updated := *old
+	updated.text = text.apply(removals, updates)
+	b.idx.Store(&updated)
+}
+
+// indexPage updates both the link index and the text index for pg
+// in a single critical section.
+func (b *bullServer) indexPage(pg *page, refs *pageRefs) {
+	b.idxMu.Lock()
+	defer b.idxMu.Unlock()
+	old, ok := b.idx.Load().links[pg.PageName]
+	if !ok || !slices.Equal(old, refs.targets) {
+		b.updateIndexLocked(pg.PageName, refs.targets)
+	}
+	b.updateTextIndexLocked(nil, []*textDoc{newTextDoc(pg, refs)})
+	if !ok {
+		b.reresolveLocked([]string{pg.PageName})
+	}
+}
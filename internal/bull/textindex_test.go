@@ -0,0 +1,252 @@
+package bull
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gokrazy/bull/internal/query"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestTokenize(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want []string
+	}{
+		{in: ""},
+		{in: "--- ..."},
+		{in: "Hello, hello World!", want: []string{"hello", "world"}},
+		{in: "Grüße aus Zürich", want: []string{"aus", "grüße", "zürich"}},
+		{in: "TODO(stapelberg): see [[days/2026-01-02]]", want: []string{"01", "02", "2026", "days", "see", "stapelberg", "todo"}},
+	} {
+		t.Run(tt.in, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, tokenize(tt.in), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("tokenize(%q): unexpected diff (-want +got):\n%s", tt.in, diff)
+			}
+		})
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func TestTextIndexCandidates(t *testing.T) {
+	text := newTextIndex(map[string]*textDoc{
+		"a": newTextDoc(&page{PageName: "a", Content: "The quick brown fox"}, nil),
+		"b": newTextDoc(&page{PageName: "b", Content: "jumps over the lazy dog"}, nil),
+		"c": newTextDoc(&page{PageName: "c", Content: "Quicksilver"}, nil),
+	})
+	for _, tt := range []struct {
+		query string
+		want  []string
+	}{
+		{query: "quick", want: []string{"a", "c"}},
+		{query: "uick", want: []string{"a", "c"}},
+		{query: "ui", want: []string{"a", "c"}},
+		{query: "x", want: []string{"a"}},
+		{query: "quick brown", want: []string{"a"}},
+		{query: "THE", want: []string{"a", "b"}},
+		{query: "cat"},
+		{query: "--", want: []string{"a", "b", "c"}},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			got := sortedKeys(text.candidates(tt.query))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("candidates(%q): unexpected diff (-want +got):\n%s", tt.query, diff)
+			}
+		})
+	}
+
+	// Updates must not modify the previous (published) index.
+	updated := text.apply([]string{"c"}, []*textDoc{
+		newTextDoc(&page{PageName: "b", Content: "a quick dog"}, nil),
+	})
+	if diff := cmp.Diff([]string{"a", "b"}, sortedKeys(updated.candidates("quick"))); diff != "" {
+		t.Errorf("after update: unexpected diff (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"a", "c"}, sortedKeys(text.candidates("quick"))); diff != "" {
+		t.Errorf("previous index modified: unexpected diff (-want +got):\n%s", diff)
+	}
+	if got := updated.postings["quicksilver"]; len(got) != 0 {
+		t.Errorf("postings[quicksilver] = %v, want []", got)
+	}
+	// The trigrams of the vocabulary are updated, too.
+	for query, want := range map[string][]string{
+		"silver": nil,
+		"lazy":   nil,
+		"a":      {"b"},
+		"dog":    {"b"},
+	} {
+		if diff := cmp.Diff(want, sortedKeys(updated.candidates(query))); diff != "" {
+			t.Errorf("after update: candidates(%q): unexpected diff (-want +got):\n%s", query, diff)
+		}
+	}
+	if diff := cmp.Diff([]string{"c"}, sortedKeys(text.candidates("silver"))); diff != "" {
+		t.Errorf("previous index modified: unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func searchPageNames(t *testing.T, b *bullServer, raw string) []string {
+	t.Helper()
+	q, err := query.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := b.internalsearch(context.Background(), q, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pageNames []string
+	for _, result := range results {
+		pageNames = append(pageNames, result.PageName)
+	}
+	return pageNames
+}
+
+func TestSearchIndexUpdates(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"alpha.md":      "the Quick brown fox",
+		"beta.md":       "lazy dog",
+		"dir/quick.md":  "nothing to see here",
+		"dir/gamma.md":  "quickly now",
+		"dir/delta.txt": "quick, but not a page",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	// dir/quick matches by name, dir/gamma is shorter than alpha.
+	want := []string{"dir/quick", "dir/gamma", "alpha"}
+	if diff := cmp.Diff(want, searchPageNames(t, b, "quick")); diff != "" {
+		t.Errorf("search(quick): unexpected diff (-want +got):\n%s", diff)
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// Modify beta (links unchanged), remove alpha.
+	if err := os.WriteFile(filepath.Join(b.contentDir, "beta.md"), []byte("quick dog"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b.handleContentEvent(w, fsnotify.Event{
+		Name: filepath.Join(b.contentDir, "beta.md"),
+		Op:   fsnotify.Write,
+	})
+	if err := os.Remove(filepath.Join(b.contentDir, "alpha.md")); err != nil {
+		t.Fatal(err)
+	}
+	b.handleContentEvent(w, fsnotify.Event{
+		Name: filepath.Join(b.contentDir, "alpha.md"),
+		Op:   fsnotify.Remove,
+	})
+
+	want = []string{"dir/quick", "beta", "dir/gamma"}
+	if diff := cmp.Diff(want, searchPageNames(t, b, "quick")); diff != "" {
+		t.Errorf("search(quick) after update: unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestIndexCache(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"alpha.md": "see [[beta]]",
+		"beta.md":  "hello world",
+	})
+	b.indexCacheDir = t.TempDir()
+	if _, err := b.index(); err != nil {
+		t.Fatal(err)
+	}
+	cache := b.loadIndexCache()
+	if diff := cmp.Diff([]string{"hello", "world"}, cache["beta"].Tokens); diff != "" {
+		t.Errorf("cache[beta].Tokens: unexpected diff (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"beta"}, cache["alpha"].Targets); diff != "" {
+		t.Errorf("cache[alpha].Targets: unexpected diff (-want +got):\n%s", diff)
+	}
+
+	// Pages whose modification time did not change are not read again.
+	links := map[string][]string{"alpha": {"gamma"}}
+	docs := map[string]*textDoc{
+		"alpha": cache["alpha"].doc(),
+		"beta":  cache["beta"].doc(),
+	}
+	docs["beta"].tokens = []string{"cache", "from"}
+	b.saveIndexCache(links, docs)
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"gamma"}, idx.links["alpha"]); diff != "" {
+		t.Errorf("links[alpha]: unexpected diff (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"cache", "from"}, idx.text.docs["beta"].tokens); diff != "" {
+		t.Errorf("text.docs[beta].tokens: unexpected diff (-want +got):\n%s", diff)
+	}
+
+	// Modified pages are read again.
+	modTime := cache["beta"].ModTime.Add(1 * time.Second)
+	if err := os.Chtimes(filepath.Join(b.contentDir, "beta.md"), modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	idx, err = b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"hello", "world"}, idx.text.docs["beta"].tokens); diff != "" {
+		t.Errorf("text.docs[beta].tokens: unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	b := newTestBull(t, map[string]string{
+		"days/2026-01-01.md": "- [ ] call the plumber #home",
+		"days/2026-01-02.md": "met with the plumber, see [[projects/bull]]",
+		"projects/bull.md":   "minimalist bullet journaling #project/bull",
+		"projects/keep.md":   "old plumber notes #project",
+	})
+	idx, err := b.index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.idx.Store(idx)
+
+	for _, tt := range []struct {
+		query string
+		want  []string
+	}{
+		{query: "plumber", want: []string{"days/2026-01-01", "days/2026-01-02", "projects/keep"}},
+		{query: "plumber -call", want: []string{"days/2026-01-02", "projects/keep"}},
+		{query: `"the plumber"`, want: []string{"days/2026-01-01", "days/2026-01-02"}},
+		{query: "plumber path:days/", want: []string{"days/2026-01-01", "days/2026-01-02"}},
+		{query: "tag:#project", want: []string{"projects/bull", "projects/keep"}},
+		{query: "tag:project/bull", want: []string{"projects/bull"}},
+		{query: "has:task", want: []string{"days/2026-01-01"}},
+		{query: "journaling OR notes", want: []string{"projects/bull", "projects/keep"}},
+		{query: "modified:>2000-01-01 tag:home", want: []string{"days/2026-01-01"}},
+		{query: "modified:<2000-01-01"},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			got := searchPageNames(t, b, tt.query)
+			slices.Sort(got) // only the set of results matters
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("search(%s): unexpected diff (-want +got):\n%s", tt.query, diff)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -78,6 +79,18 @@ func (b *bullServer) handleWatchBrowse(ctx context.Context, w http.ResponseWrite
 	sortby := r.FormValue("sort")
 	sortorder := r.FormValue("sortorder")
 	directories := r.FormValue("directories")
+	// TODO: browseContentHash walks the entire content
+	// directory. Consider adding debounce or caching if
+	// this becomes a bottleneck with large wikis.
+	return b.handleWatchGenerated(ctx, w, flusher, r, func() (string, error) {
+		return b.browseContentHash(dir, sortby, sortorder, directories)
+	})
+}
+
+// handleWatchGenerated notifies the client when the content of a generated
+// page changes. Instead of watching a file, it re-generates the page content
+// (using contentHash) whenever any content changes.
+func (b *bullServer) handleWatchGenerated(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, r *http.Request, contentHash func() (string, error)) error {
 	rhash := r.FormValue("hash")
 
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -92,9 +105,9 @@ func (b *bullServer) handleWatchBrowse(ctx context.Context, w http.ResponseWrite
 	// (e.g. during page reload), analogous to the hash check
 	// in the regular page watcher.
 	if rhash != "" {
-		current, err := b.browseContentHash(dir, sortby, sortorder, directories)
+		current, err := contentHash()
 		if err != nil {
-			log.Printf("browseContentHash (initial): %v", err)
+			log.Printf("contentHash (initial): %v", err)
 		} else if current != rhash {
 			w.Write([]byte("data: {\"changed\":true}\n\n"))
 			flusher.Flush()
@@ -113,12 +126,9 @@ func (b *bullServer) handleWatchBrowse(ctx context.Context, w http.ResponseWrite
 			contentChanged = b.contentChangedCh()
 
 			if rhash != "" {
-				// TODO: browseContentHash walks the entire content
-				// directory. Consider adding debounce or caching if
-				// this becomes a bottleneck with large wikis.
-				current, err := b.browseContentHash(dir, sortby, sortorder, directories)
+				current, err := contentHash()
 				if err != nil {
-					log.Printf("browseContentHash (watch loop): %v", err)
+					log.Printf("contentHash (watch loop): %v", err)
 					// On error, notify the client to reload rather than
 					// silently sitting idle.
 				} else if current == rhash {
@@ -144,6 +154,57 @@ func (b *bullServer) handleWatch(w http.ResponseWriter, r *http.Request) error {
 	if pageName == bullPrefix+"browse" {
 		return b.handleWatchBrowse(ctx, w, flusher, r)
 	}
+	if pageName == bullPrefix+"tags" {
+		return b.handleWatchGenerated(ctx, w, flusher, r, func() (string, error) {
+			return hashSum(b.tagsContent()), nil
+		})
+	}
+	if pageName == bullPrefix+"health" {
+		return b.handleWatchGenerated(ctx, w, flusher, r, func() (string, error) {
+			return hashSum(b.healthContent()), nil
+		})
+	}
+	if pageName == bullPrefix+"tasks" {
+		f, err := parseTaskFilter(r)
+		if err != nil {
+			return err
+		}
+		return b.handleWatchGenerated(ctx, w, flusher, r, func() (string, error) {
+			return hashSum(b.tasksContent(f)), nil
+		})
+	}
+	if root, ok := strings.CutPrefix(pageName, bullPrefix+"graph/"); ok {
+		depth, err := graphDepth(r)
+		if err != nil {
+			return err
+		}
+		return b.handleWatchGenerated(ctx, w, flusher, r, func() (string, error) {
+			md, err := b.graphContent(root, depth)
+			if err != nil {
+				return "", err
+			}
+			return hashSum(md), nil
+		})
+	}
+	if target, ok := strings.CutPrefix(pageName, bullPrefix+"mentions/"); ok {
+		return b.handleWatchGenerated(ctx, w, flusher, r, func() (string, error) {
+			return hashSum(b.mentionsContent(target)), nil
+		})
+	}
+	if tag, ok := strings.CutPrefix(pageName, bullPrefix+"tag/"); ok {
+		return b.handleWatchGenerated(ctx, w, flusher, r, func() (string, error) {
+			return hashSum(b.tagContent(tag)), nil
+		})
+	}
+	if name, ok := strings.CutPrefix(pageName, bullPrefix+savedQueryPrefix); ok {
+		return b.handleWatchGenerated(ctx, w, flusher, r, func() (string, error) {
+			md, err := b.savedQueryContent(ctx, name)
+			if err != nil {
+				return "", err
+			}
+			return hashSum(md), nil
+		})
+	}
 
 	possibilities := filesFromURL(r)
 	lastb, err := b.readFirst(possibilities)
@@ -158,12 +219,13 @@ func (b *bullServer) handleWatch(w http.ResponseWriter, r *http.Request) error {
 	// so that we can immediately emit a change even when the client was
 	// not connected during the time of the actual change.
 	if rhash := r.FormValue("hash"); rhash != "" {
-		if current := lastb.ContentHash(); current != rhash {
+		if current := b.contentHash(lastb); current != rhash {
 			w.Write([]byte("data: {\"changed\":true}\n\n"))
 			flusher.Flush()
 		}
 	}
 
+	lastHash := b.contentHash(lastb)
 	notify := make(chan struct{})
 	maybeNotify(ctx, notify, filepath.Join(b.contentDir, lastb.FileName))
 
@@ -181,14 +243,16 @@ func (b *bullServer) handleWatch(w http.ResponseWriter, r *http.Request) error {
 			// Backlinks or other content changed; re-check the page.
 		}
 
-		b, err := b.readFirst(possibilities)
+		pg, err := b.readFirst(possibilities)
 		if err != nil {
 			return err
 		}
-		if lastb.Content == b.Content {
+		// contentHash covers embedded pages (if any).
+		if hash := b.contentHash(pg); hash == lastHash {
 			continue
+		} else {
+			lastHash = hash
 		}
-		lastb = b
 		w.Write([]byte("data: {\"changed\":true}\n\n"))
 		flusher.Flush()
 	}
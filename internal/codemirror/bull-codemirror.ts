@@ -18,6 +18,31 @@ import {defaultHighlightStyle, syntaxHighlighting, indentOnInput, bracketMatchin
 import {defaultKeymap, history, historyKeymap, indentWithTab} from "@codemirror/commands"
 import {searchKeymap, highlightSelectionMatches} from "@codemirror/search"
 import {lintKeymap} from "@codemirror/lint"
+import {autocompletion, completionKeymap, startCompletion,
+        CompletionContext, CompletionResult} from "@codemirror/autocomplete"
+
+declare var BullURLBullPrefix: string
+
+// wikilinkCompletions completes page names after [[ using the fuzzy page
+// name matcher on the server.
+async function wikilinkCompletions(context: CompletionContext): Promise<CompletionResult | null> {
+    const link = context.matchBefore(/\[\[[^\]|\n]*/);
+    if (link === null) {
+	return null;
+    }
+    const q = link.text.slice(2);
+    const resp = await fetch(BullURLBullPrefix + '_pages?limit=20&q=' + encodeURIComponent(q));
+    if (!resp.ok) {
+	return null;
+    }
+    const matches: {page_name: string}[] = await resp.json();
+    return {
+	from: link.from + 2,
+	options: matches.map(m => ({label: m.page_name})),
+	// The server already ranked the matches.
+	filter: false,
+    };
+}
 
 let bullSetup = [
     lineNumbers(),
@@ -37,8 +62,13 @@ let bullSetup = [
 
     // The autocompletion extension installs an alt+p / alt+z
     // keyboard shortcut, which prevents me from entering
-    // ~ or ` when using neo-layout.org.
-    // autocompletion(),
+    // ~ or ` when using neo-layout.org. Hence, only [[ wiki link
+    // completion is enabled, and without the default keymap (the
+    // keys for navigating the completion list are bound below).
+    autocompletion({
+	override: [wikilinkCompletions],
+	defaultKeymap: false,
+    }),
 
     rectangularSelection(),
     crosshairCursor(),
@@ -50,6 +80,7 @@ let bullSetup = [
 	...historyKeymap,
 	...lintKeymap,
 	...searchKeymap,
+	...completionKeymap.filter(binding => binding.run !== startCompletion),
 	// TODO: document why indentWithTab breaks with ...
 	// prepended, but others need it?!
 	indentWithTab,
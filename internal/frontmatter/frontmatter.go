@@ -0,0 +1,281 @@
+// Package frontmatter parses the optional metadata at the start of a page,
+// either YAML (delimited by ---) or TOML (delimited by +++):
+//
+//	---
+//	title: Pancakes
+//	tags: [recipe, breakfast]
+//	aliases:
+//	  - crêpes
+//	created: 2026-01-02
+//	---
+//
+// Of YAML front matter, bull uses the keys whose values are scalars or lists
+// of scalars. Nested mappings are ignored. A YAML document which is not a
+// mapping is not front matter, but e.g. text between two thematic breaks.
+package frontmatter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatter is the parsed front matter of a page.
+type FrontMatter struct {
+	Format string // "yaml" or "toml"
+	Len    int    // length (in bytes) including delimiters
+
+	Title   string
+	Tags    []string // without #
+	Aliases []string
+	Created time.Time // zero if unset or not a valid date
+
+	// Fields contains all keys (including the well-known keys above).
+	// List values are joined with ", ".
+	Fields map[string]string
+}
+
+// Field returns the value of key (see Fields), which is case-insensitive.
+func (fm *FrontMatter) Field(key string) (string, bool) {
+	if fm == nil {
+		return "", false
+	}
+	if value, ok := fm.Fields[key]; ok {
+		return value, true
+	}
+	for k, value := range fm.Fields {
+		if strings.EqualFold(k, key) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+var delimiters = []struct {
+	delim  string
+	format string
+}{
+	{"---", "yaml"},
+	{"+++", "toml"},
+}
+
+// split returns the format and raw content of the front matter of content,
+// and the length of the front matter including delimiters.
+func split(content string) (format, raw string, n int, ok bool) {
+	for _, d := range delimiters {
+		rest, ok := strings.CutPrefix(content, d.delim+"\n")
+		if !ok {
+			rest, ok = strings.CutPrefix(content, d.delim+"\r\n")
+		}
+		if !ok {
+			continue
+		}
+		start := len(content) - len(rest)
+		for off := start; off < len(content); {
+			line, _, _ := strings.Cut(content[off:], "\n")
+			next := off + len(line) + 1
+			if next > len(content) {
+				next = len(content)
+			}
+			trimmed := strings.TrimRight(line, " \t\r")
+			if trimmed == d.delim || (d.format == "yaml" && trimmed == "...") {
+				return d.format, content[start:off], next, true
+			}
+			off = next
+		}
+		return "", "", 0, false // no closing delimiter
+	}
+	return "", "", 0, false
+}
+
+// Parse parses the front matter at the start of content. It returns nil
+// (and no error) if content does not start with front matter.
+func Parse(content string) (*FrontMatter, error) {
+	format, raw, n, ok := split(content)
+	if !ok {
+		return nil, nil
+	}
+	var values map[string][]string
+	var err error
+	switch format {
+	case "yaml":
+		values, err = parseYAML(raw)
+	case "toml":
+		values, err = parseTOML(raw)
+	}
+	if err == errNotFrontMatter {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s front matter: %v", format, err)
+	}
+	fm := &FrontMatter{
+		Format: format,
+		Len:    n,
+		Fields: make(map[string]string, len(values)),
+	}
+	for key, vals := range values {
+		fm.Fields[key] = strings.Join(vals, ", ")
+		switch strings.ToLower(key) {
+		case "title":
+			fm.Title = strings.Join(vals, ", ")
+		case "tags":
+			for _, val := range vals {
+				// Tags can also be specified as a single string,
+				// e.g. tags: recipe, breakfast
+				for _, tag := range strings.FieldsFunc(val, func(r rune) bool {
+					return r == ',' || unicode.IsSpace(r)
+				}) {
+					fm.Tags = append(fm.Tags, strings.TrimPrefix(tag, "#"))
+				}
+			}
+		case "aliases":
+			fm.Aliases = append(fm.Aliases, vals...)
+		}
+	}
+	created, ok := fm.Field("created")
+	if !ok {
+		created, _ = fm.Field("date")
+	}
+	fm.Created = parseDate(created)
+	return fm, nil
+}
+
+// Blank returns content with its front matter (if any) replaced by spaces,
+// which keeps byte offsets and line numbers intact for the markdown parser.
+func Blank(content string) string {
+	fm, err := Parse(content)
+	if err != nil || fm == nil {
+		return content
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '\n' {
+			return r
+		}
+		return ' '
+	}, content[:fm.Len]) + content[fm.Len:]
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func parseDate(value string) time.Time {
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// errNotFrontMatter is returned by parseYAML if raw is not a YAML mapping,
+// e.g. when a page starts with a thematic break (---) instead of front matter.
+var errNotFrontMatter = errors.New("not front matter")
+
+// yamlKeyRegexp matches the first line of a YAML mapping.
+var yamlKeyRegexp = regexp.MustCompile(`^[^\s#:-][^:]*:(\s|$)`)
+
+// parseYAML returns the top-level keys of the YAML mapping raw with their
+// scalar or list values. Nested mappings are ignored.
+func parseYAML(raw string) (map[string][]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(raw), &doc); err != nil {
+		// Only report errors if raw was meant to be a mapping.
+		for line := range strings.SplitSeq(raw, "\n") {
+			if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			if yamlKeyRegexp.MatchString(line) {
+				return nil, err
+			}
+			break
+		}
+		return nil, errNotFrontMatter
+	}
+	values := make(map[string][]string)
+	if len(doc.Content) == 0 {
+		return values, nil // empty front matter
+	}
+	mapping := yamlAlias(doc.Content[0])
+	if mapping.Kind != yaml.MappingNode {
+		return nil, errNotFrontMatter
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i].Value, yamlAlias(mapping.Content[i+1])
+		switch value.Kind {
+		case yaml.ScalarNode:
+			if value.Tag == "!!null" {
+				values[key] = nil
+			} else {
+				values[key] = []string{value.Value}
+			}
+		case yaml.SequenceNode:
+			var vals []string
+			for _, item := range value.Content {
+				if item := yamlAlias(item); item.Kind == yaml.ScalarNode {
+					vals = append(vals, item.Value)
+				}
+			}
+			values[key] = vals
+		}
+	}
+	return values, nil
+}
+
+// yamlAlias returns the node which n refers to if n is an alias (*name).
+func yamlAlias(n *yaml.Node) *yaml.Node {
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		return n.Alias
+	}
+	return n
+}
+
+func parseTOML(raw string) (map[string][]string, error) {
+	var decoded map[string]any
+	if _, err := toml.Decode(raw, &decoded); err != nil {
+		return nil, err
+	}
+	values := make(map[string][]string, len(decoded))
+	for key, value := range decoded {
+		if list, ok := value.([]any); ok {
+			vals := make([]string, len(list))
+			for idx, item := range list {
+				vals[idx] = tomlString(item)
+			}
+			values[key] = vals
+			continue
+		}
+		values[key] = []string{tomlString(value)}
+	}
+	return values, nil
+}
+
+func tomlString(value any) string {
+	t, ok := value.(time.Time)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	// The TOML decoder uses these location names for values
+	// without a time zone, see github.com/BurntSushi/toml/internal.
+	switch t.Location().String() {
+	case "date-local":
+		return t.Format("2006-01-02")
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05")
+	case "time-local":
+		return t.Format("15:04:05")
+	}
+	return t.Format(time.RFC3339)
+}
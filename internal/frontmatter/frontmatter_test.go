@@ -0,0 +1,142 @@
+package frontmatter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParse(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		content string
+		want    *FrontMatter
+		wantErr bool
+	}{
+		{
+			name:    "none",
+			content: "# heading\n\ntext\n",
+		},
+		{
+			name:    "thematic break",
+			content: "---\n\nno closing delimiter\n",
+		},
+		{
+			name: "yaml",
+			content: "---\n" +
+				"title: \"Pancakes: a recipe\"\n" +
+				"tags: [recipe, '#breakfast']\n" +
+				"aliases:\n" +
+				"  - crêpes\n" +
+				"  - 'flapjacks'\n" +
+				"# a comment\n" +
+				"created: 2026-01-02\n" +
+				"author: Jane # the cook\n" +
+				"---\n" +
+				"body\n",
+			want: &FrontMatter{
+				Format:  "yaml",
+				Len:     158,
+				Title:   "Pancakes: a recipe",
+				Tags:    []string{"recipe", "breakfast"},
+				Aliases: []string{"crêpes", "flapjacks"},
+				Created: time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local),
+				Fields: map[string]string{
+					"title":   "Pancakes: a recipe",
+					"tags":    "recipe, #breakfast",
+					"aliases": "crêpes, flapjacks",
+					"created": "2026-01-02",
+					"author":  "Jane",
+				},
+			},
+		},
+		{
+			name:    "yaml tags string",
+			content: "---\ntags: recipe breakfast\n---\n",
+			want: &FrontMatter{
+				Format: "yaml",
+				Len:    31,
+				Tags:   []string{"recipe", "breakfast"},
+				Fields: map[string]string{"tags": "recipe breakfast"},
+			},
+		},
+		{
+			name: "toml",
+			content: "+++\n" +
+				"title = \"Waffles\"\n" +
+				"tags = [\"recipe\"]\n" +
+				"date = 2026-03-04\n" +
+				"servings = 4\n" +
+				"+++\n" +
+				"body\n",
+			want: &FrontMatter{
+				Format:  "toml",
+				Len:     75,
+				Title:   "Waffles",
+				Tags:    []string{"recipe"},
+				Created: time.Date(2026, 3, 4, 0, 0, 0, 0, time.Local),
+				Fields: map[string]string{
+					"title":    "Waffles",
+					"tags":     "recipe",
+					"date":     "2026-03-04",
+					"servings": "4",
+				},
+			},
+		},
+		{
+			name:    "yaml nested",
+			content: "---\ntitle: \"a\\ b\"\nauthor:\n  name: Jane\nlinks:\n  - url: https://example.com/\n---\n",
+			want: &FrontMatter{
+				Format: "yaml",
+				Len:    80,
+				Title:  "a b",
+				Fields: map[string]string{"title": "a b", "links": ""},
+			},
+		},
+		{
+			name:    "yaml thematic breaks",
+			content: "---\nsome text\n---\n",
+		},
+		{
+			name:    "yaml thematic breaks around a list",
+			content: "---\n* item\n* item: with colon\n---\n",
+		},
+		{
+			name:    "yaml invalid",
+			content: "---\ntitle: [unterminated\n---\n",
+			wantErr: true,
+		},
+		{
+			name:    "toml invalid",
+			content: "+++\ntitle = \n+++\n",
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse: err = %v, wantErr = %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+				t.Errorf("Parse: unexpected diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBlank(t *testing.T) {
+	content := "---\ntitle: x\n---\n# heading\n"
+	got := Blank(content)
+	if want := "   \n        \n   \n# heading\n"; got != want {
+		t.Errorf("Blank(%q) = %q, want %q", content, got, want)
+	}
+	if got, want := strings.Count(got, "\n"), strings.Count(content, "\n"); got != want {
+		t.Errorf("Blank changed the number of lines: got %d, want %d", got, want)
+	}
+	if content := "no front matter\n"; Blank(content) != content {
+		t.Errorf("Blank(%q) modified content without front matter", content)
+	}
+}
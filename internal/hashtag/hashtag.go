@@ -81,6 +81,20 @@ func (p *Parser) Parse(_ ast.Node, block text.Reader, _ parser.Context) ast.Node
 
 var _ parser.InlineParser = (*Parser)(nil)
 
+// Extract returns all hashtags (without the leading #) of the document n,
+// which was parsed with the Extender installed. Hashtags in code spans and
+// code blocks are not hashtags and hence not returned.
+func Extract(n ast.Node) []string {
+	var tags []string
+	ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if ht, ok := n.(*Node); ok && entering {
+			tags = append(tags, string(bytes.TrimPrefix(ht.Tag, trigger)))
+		}
+		return ast.WalkContinue, nil
+	})
+	return tags
+}
+
 type Renderer struct {
 	urlBullPrefix string
 }
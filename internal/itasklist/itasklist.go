@@ -5,8 +5,10 @@ package itasklist
 import (
 	"bytes"
 	"fmt"
+	"html"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
@@ -16,7 +18,43 @@ import (
 	"github.com/yuin/goldmark/util"
 )
 
-var taskListRegexp = regexp.MustCompile(`^\[([\sxX])\]\s*`)
+var taskListRegexp = regexp.MustCompile(`^\[([\sxX/><-])\]\s*`)
+
+// StateNames maps the task states, i.e. the characters between the brackets
+// of a task list item, to their names, which the renderer uses as CSS classes
+// (itask_<name>). Besides open and done tasks, the bullet journal states are
+// recognized.
+var StateNames = map[byte]string{
+	' ': "open",
+	'x': "done",
+	'X': "done",
+	'/': "inprogress",
+	'>': "migrated",
+	'<': "scheduled",
+	'-': "cancelled",
+}
+
+// DefaultStates is the order in which clicking a task cycles through
+// states, unless configured otherwise.
+var DefaultStates = []string{" ", "x"}
+
+// NextState returns the state following state in the cycle states
+// (DefaultStates if empty). States which are not part of the cycle
+// continue with the first state of the cycle.
+func NextState(states []string, state string) string {
+	if len(states) == 0 {
+		states = DefaultStates
+	}
+	if state == "X" {
+		state = "x"
+	}
+	for i, s := range states {
+		if s == state {
+			return states[(i+1)%len(states)]
+		}
+	}
+	return states[0]
+}
 
 var TaskListKind = ast.NewNodeKind("itasklist")
 
@@ -40,6 +78,7 @@ type TaskItemNode struct {
 	ast.BaseInline
 
 	IsChecked bool
+	State     byte // see StateNames
 	StartByte int
 }
 
@@ -48,6 +87,7 @@ func (*TaskItemNode) Kind() ast.NodeKind { return TaskItemKind }
 func (n *TaskItemNode) Dump(src []byte, level int) {
 	ast.DumpHelper(n, src, level, map[string]string{
 		"IsChecked": fmt.Sprint(n.IsChecked),
+		"State":     string(n.State),
 		"StartByte": strconv.Itoa(n.StartByte),
 	}, nil)
 }
@@ -94,6 +134,7 @@ func (p *TaskItemParser) Parse(parent ast.Node, block text.Reader, _ parser.Cont
 	checked := value == 'x' || value == 'X'
 	return &TaskItemNode{
 		IsChecked: checked,
+		State:     value,
 		StartByte: seg.Start,
 	}
 }
@@ -103,6 +144,7 @@ var _ parser.InlineParser = (*TaskItemParser)(nil)
 type TaskListRenderer struct {
 	URLBullPrefix string
 	PageURLPath   string // already escaped with url.URL.EscapedPath
+	Disabled      bool
 }
 
 func (r *TaskListRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
@@ -110,6 +152,9 @@ func (r *TaskListRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer
 }
 
 func (r *TaskListRenderer) renderItasklist(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if r.Disabled {
+		return ast.WalkContinue, nil
+	}
 	if entering {
 		w.WriteString("<form class=\"itasklist\" action=\"" + r.URLBullPrefix + "_itasklist/" + r.PageURLPath + "\" method=\"POST\">\n")
 	} else {
@@ -121,7 +166,9 @@ func (r *TaskListRenderer) renderItasklist(w util.BufWriter, _ []byte, node ast.
 
 var _ renderer.NodeRenderer = (*TaskListRenderer)(nil)
 
-type TaskItemRenderer struct{}
+type TaskItemRenderer struct {
+	Disabled bool
+}
 
 func (r *TaskItemRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
 	reg.Register(TaskItemKind, r.renderItaskitem)
@@ -133,14 +180,27 @@ func (r *TaskItemRenderer) renderItaskitem(w util.BufWriter, source []byte, node
 	}
 	n := node.(*TaskItemNode)
 
-	if n.IsChecked {
-		w.WriteString(`<input checked="" type="checkbox"`)
+	w.WriteString(Checkbox(n.State, lineNumber(source, n.StartByte), r.Disabled))
+	return ast.WalkContinue, nil
+}
+
+// Checkbox returns the HTML checkbox input element of a task in state
+// (see StateNames) in line line (1-based) of its page.
+func Checkbox(state byte, line int, disabled bool) string {
+	var sb strings.Builder
+	if state == 'x' || state == 'X' {
+		sb.WriteString(`<input checked="" type="checkbox"`)
 	} else {
-		w.WriteString(`<input type="checkbox"`)
+		sb.WriteString(`<input type="checkbox"`)
 	}
-	line := lineNumber(source, n.StartByte)
-	w.WriteString(` data-line="` + strconv.Itoa(line) + `">`)
-	return ast.WalkContinue, nil
+	if disabled {
+		sb.WriteString(` disabled=""`)
+	}
+	name := StateNames[state]
+	sb.WriteString(` class="itask_` + name + `" title="` + name + `"`)
+	sb.WriteString(` data-state="` + html.EscapeString(string(state)) + `"`)
+	sb.WriteString(` data-line="` + strconv.Itoa(line) + `">`)
+	return sb.String()
 }
 
 var _ renderer.NodeRenderer = (*TaskItemRenderer)(nil)
@@ -148,6 +208,10 @@ var _ renderer.NodeRenderer = (*TaskItemRenderer)(nil)
 type Extender struct {
 	URLBullPrefix string
 	PageURLPath   string // already escaped with url.URL.EscapedPath
+
+	// Disabled renders task lists read-only: disabled checkboxes,
+	// without a form to toggle them.
+	Disabled bool
 }
 
 func (e *Extender) Extend(m goldmark.Markdown) {
@@ -158,7 +222,9 @@ func (e *Extender) Extend(m goldmark.Markdown) {
 	)
 	m.Renderer().AddOptions(
 		renderer.WithNodeRenderers(
-			util.Prioritized(&TaskItemRenderer{}, 500),
+			util.Prioritized(&TaskItemRenderer{
+				Disabled: e.Disabled,
+			}, 500),
 		),
 	)
 	m.Renderer().AddOptions(
@@ -166,6 +232,7 @@ func (e *Extender) Extend(m goldmark.Markdown) {
 			util.Prioritized(&TaskListRenderer{
 				URLBullPrefix: e.URLBullPrefix,
 				PageURLPath:   e.PageURLPath,
+				Disabled:      e.Disabled,
 			}, 999),
 		),
 	)
@@ -0,0 +1,732 @@
+// Package query implements the bull search query language.
+//
+// A query consists of terms, which all need to match (implicit AND):
+//
+//	foo bar             pages containing foo and bar (in name or content)
+//	"foo bar"           pages containing the phrase “foo bar”
+//	-foo                pages not containing foo
+//	foo OR bar          pages containing foo or bar (AND binds tighter)
+//	(foo OR bar) baz    parentheses group terms
+//	path:days/          pages whose name starts with days/
+//	tag:#project        pages tagged #project (or #project/…)
+//	modified:>2026-01-01  pages modified after 2026-01-01 (also >=, <, <=)
+//	has:task            pages containing a task list item
+//	meta:author         pages whose front matter contains an author key
+//	meta:author=jane    pages whose front matter author contains jane
+//	meta:date>=2026-01  pages whose front matter date sorts after 2026-01
+//	                    (also >, <, <=; values are compared as strings)
+//
+// All text matching is case-insensitive.
+//
+// Alternatively, ParseRegexp parses a regular expression query, which
+// matches pages whose name or content match the regular expression.
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// A Doc is a page that a Query can be matched against.
+type Doc interface {
+	// Name returns the page name, e.g. days/2026-01-02.
+	Name() string
+
+	// Text returns the page content.
+	Text() string
+
+	// Modified returns the last modification time of the page.
+	Modified() time.Time
+
+	// HasTag returns whether the page is tagged with tag (without #),
+	// or with a hierarchical tag below tag (e.g. tag/sub).
+	HasTag(tag string) bool
+
+	// Has returns whether the page contains feature, e.g. "task".
+	Has(feature string) bool
+
+	// Meta returns the value of the front matter key of the page.
+	Meta(key string) (string, bool)
+}
+
+// Features lists the supported values of has: filters.
+var Features = []string{"task"}
+
+// A Node is an element of a parsed query.
+type Node interface {
+	match(m *matcher) bool
+}
+
+// Term matches pages whose name or content contains Text.
+type Term struct {
+	Text   string // lower-cased
+	Phrase bool   // whether the term was quoted
+}
+
+// Not matches pages that X does not match.
+type Not struct {
+	X Node
+}
+
+// And matches pages that all of Xs match.
+type And struct {
+	Xs []Node
+}
+
+// Or matches pages that any of Xs match.
+type Or struct {
+	Xs []Node
+}
+
+// Path matches pages whose name starts with Prefix.
+type Path struct {
+	Prefix string
+}
+
+// Tag matches pages tagged with Tag (without #).
+type Tag struct {
+	Tag string
+}
+
+// Modified matches pages whose modification time is within [After, Before).
+// A zero After or Before means the range is unbounded on that side.
+type Modified struct {
+	After, Before time.Time
+}
+
+// Has matches pages containing Feature.
+type Has struct {
+	Feature string
+}
+
+// Meta matches pages whose front matter contains Key. If Op is not empty,
+// the value of Key must contain Value (Op "="), or compare to Value as
+// specified by Op (">", ">=", "<", "<=").
+type Meta struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+// Regexp matches pages whose name or content matches Re.
+type Regexp struct {
+	Re *regexp.Regexp
+
+	literals []string // lower-cased, contained in every match of Re
+}
+
+// A Query is a parsed search query.
+type Query struct {
+	raw  string
+	root Node
+}
+
+// String returns the query as entered by the user.
+func (q *Query) String() string { return q.raw }
+
+// Root returns the root node of the query syntax tree.
+func (q *Query) Root() Node { return q.root }
+
+type matcher struct {
+	doc       Doc
+	lowerText string
+	lowerName string
+	lowered   bool
+}
+
+func (m *matcher) lower() {
+	if m.lowered {
+		return
+	}
+	m.lowerText = strings.ToLower(m.doc.Text())
+	m.lowerName = strings.ToLower(m.doc.Name())
+	m.lowered = true
+}
+
+func (t *Term) match(m *matcher) bool {
+	m.lower()
+	return strings.Contains(m.lowerName, t.Text) ||
+		strings.Contains(m.lowerText, t.Text)
+}
+
+func (n *Not) match(m *matcher) bool { return !n.X.match(m) }
+
+func (a *And) match(m *matcher) bool {
+	for _, x := range a.Xs {
+		if !x.match(m) {
+			return false
+		}
+	}
+	return true
+}
+
+func (o *Or) match(m *matcher) bool {
+	for _, x := range o.Xs {
+		if x.match(m) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Path) match(m *matcher) bool {
+	return strings.HasPrefix(m.doc.Name(), p.Prefix)
+}
+
+func (t *Tag) match(m *matcher) bool {
+	return m.doc.HasTag(t.Tag)
+}
+
+func (mod *Modified) match(m *matcher) bool {
+	modTime := m.doc.Modified()
+	if !mod.After.IsZero() && modTime.Before(mod.After) {
+		return false
+	}
+	if !mod.Before.IsZero() && !modTime.Before(mod.Before) {
+		return false
+	}
+	return true
+}
+
+func (h *Has) match(m *matcher) bool {
+	return m.doc.Has(h.Feature)
+}
+
+func (mt *Meta) match(m *matcher) bool {
+	value, ok := m.doc.Meta(mt.Key)
+	if !ok {
+		return false
+	}
+	switch mt.Op {
+	case "":
+		return true
+	case "=":
+		return strings.Contains(strings.ToLower(value), mt.Value)
+	case ">":
+		return value > mt.Value
+	case ">=":
+		return value >= mt.Value
+	case "<":
+		return value < mt.Value
+	case "<=":
+		return value <= mt.Value
+	}
+	return false
+}
+
+func (r *Regexp) match(m *matcher) bool {
+	return r.Re.MatchString(m.doc.Name()) || r.Re.MatchString(m.doc.Text())
+}
+
+// Match returns whether doc matches the query.
+func (q *Query) Match(doc Doc) bool {
+	return q.root.match(&matcher{doc: doc})
+}
+
+// A Result is the outcome of MatchIndexed.
+type Result int
+
+const (
+	NoMatch Result = iota // doc does not match, regardless of its content
+	Match                 // doc matches, regardless of its content
+	Unknown               // whether doc matches depends on its content
+)
+
+// MatchIndexed is like Match, but does not look at the content of doc
+// (Text is never called), so that pages which cannot match can be skipped
+// without reading them. Terms are matched against the page name, and
+// mayContain reports whether the content of doc might contain term (false
+// if it definitely does not, e.g. according to an index).
+func (q *Query) MatchIndexed(doc Doc, mayContain func(term string) bool) Result {
+	m := &matcher{doc: doc}
+	var eval func(n Node) Result
+	eval = func(n Node) Result {
+		switch n := n.(type) {
+		case *Term:
+			switch {
+			case strings.Contains(strings.ToLower(doc.Name()), n.Text):
+				return Match
+			case !mayContain(n.Text):
+				return NoMatch
+			}
+			return Unknown
+		case *Regexp:
+			if n.Re.MatchString(doc.Name()) {
+				return Match
+			}
+			return Unknown
+		case *Not:
+			switch eval(n.X) {
+			case Match:
+				return NoMatch
+			case NoMatch:
+				return Match
+			}
+			return Unknown
+		case *And:
+			result := Match
+			for _, x := range n.Xs {
+				switch eval(x) {
+				case NoMatch:
+					return NoMatch
+				case Unknown:
+					result = Unknown
+				}
+			}
+			return result
+		case *Or:
+			result := NoMatch
+			for _, x := range n.Xs {
+				switch eval(x) {
+				case Match:
+					return Match
+				case Unknown:
+					result = Unknown
+				}
+			}
+			return result
+		}
+		// Filters (path:, tag:, …) do not look at the content.
+		if n.match(m) {
+			return Match
+		}
+		return NoMatch
+	}
+	return eval(q.root)
+}
+
+// Regexp returns the regular expression of a query parsed by ParseRegexp,
+// or nil for other queries.
+func (q *Query) Regexp() *regexp.Regexp {
+	if r, ok := q.root.(*Regexp); ok {
+		return r.Re
+	}
+	return nil
+}
+
+// Terms returns the text of all terms that contribute to a match, i.e. all
+// terms that are not negated. Use Terms for highlighting matches.
+func (q *Query) Terms() []string {
+	var terms []string
+	var walk func(n Node)
+	walk = func(n Node) {
+		switch n := n.(type) {
+		case *Term:
+			terms = append(terms, n.Text)
+		case *And:
+			for _, x := range n.Xs {
+				walk(x)
+			}
+		case *Or:
+			for _, x := range n.Xs {
+				walk(x)
+			}
+		}
+	}
+	walk(q.root)
+	return terms
+}
+
+// Required returns the text of all terms that every matching page must
+// contain, which allows narrowing down the pages to consider with an index.
+func (q *Query) Required() []string {
+	var terms []string
+	var walk func(n Node)
+	walk = func(n Node) {
+		switch n := n.(type) {
+		case *Term:
+			terms = append(terms, n.Text)
+		case *Regexp:
+			terms = append(terms, n.literals...)
+		case *And:
+			for _, x := range n.Xs {
+				walk(x)
+			}
+		}
+	}
+	walk(q.root)
+	return terms
+}
+
+// requiredLiterals returns (lower-cased) literal strings which every match
+// of re contains, e.g. "todo(" and ")" for TODO\(\w+\).
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return []string{strings.ToLower(string(re.Rune))}
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpConcat:
+		var literals []string
+		for _, sub := range re.Sub {
+			literals = append(literals, requiredLiterals(sub)...)
+		}
+		return literals
+	}
+	// Alternations, optional parts, character classes, …
+	// do not require any particular literal.
+	return nil
+}
+
+// SyntaxError describes a problem with the query syntax.
+type SyntaxError struct {
+	Query string
+	Msg   string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid query %q: %s", e.Query, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokPhrase
+	tokMinus
+	tokLParen
+	tokRParen
+	tokOr
+	tokAnd
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	field string // for tokWord and tokPhrase: e.g. "path" for path:days/
+}
+
+var fields = map[string]bool{
+	"path":     true,
+	"tag":      true,
+	"modified": true,
+	"has":      true,
+	"meta":     true,
+}
+
+func lex(s string) []token {
+	var tokens []token
+	for {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		if s == "" {
+			return tokens
+		}
+		switch s[0] {
+		case '(':
+			tokens = append(tokens, token{kind: tokLParen})
+			s = s[1:]
+			continue
+		case ')':
+			tokens = append(tokens, token{kind: tokRParen})
+			s = s[1:]
+			continue
+		case '-':
+			if len(s) > 1 && !unicode.IsSpace(rune(s[1])) {
+				tokens = append(tokens, token{kind: tokMinus})
+				s = s[1:]
+				continue
+			}
+		}
+
+		var field string
+		if colon := strings.IndexByte(s, ':'); colon > 0 {
+			if f := s[:colon]; fields[f] && colon+1 < len(s) && !unicode.IsSpace(rune(s[colon+1])) {
+				field = f
+				s = s[colon+1:]
+			}
+		}
+
+		if s[0] == '"' {
+			end := strings.IndexByte(s[1:], '"')
+			var phrase string
+			if end == -1 {
+				// unterminated phrase: extend until the end of the query
+				phrase, s = s[1:], ""
+			} else {
+				phrase, s = s[1:1+end], s[1+end+1:]
+			}
+			tokens = append(tokens, token{kind: tokPhrase, text: phrase, field: field})
+			continue
+		}
+
+		end := strings.IndexFunc(s, func(r rune) bool {
+			return unicode.IsSpace(r) || r == '(' || r == ')'
+		})
+		if end == -1 {
+			end = len(s)
+		}
+		word := s[:end]
+		s = s[end:]
+		switch {
+		case field == "" && word == "OR":
+			tokens = append(tokens, token{kind: tokOr})
+		case field == "" && word == "AND":
+			tokens = append(tokens, token{kind: tokAnd})
+		default:
+			tokens = append(tokens, token{kind: tokWord, text: word, field: field})
+		}
+	}
+}
+
+type parser struct {
+	query  string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &SyntaxError{Query: p.query, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// parseOr parses: and { OR and }
+func (p *parser) parseOr() (Node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	xs := []Node{first}
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind != tokOr {
+			break
+		}
+		p.pos++
+		x, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		xs = append(xs, x)
+	}
+	if len(xs) == 1 {
+		return first, nil
+	}
+	return &Or{Xs: xs}, nil
+}
+
+// parseAnd parses: unary { [AND] unary }
+func (p *parser) parseAnd() (Node, error) {
+	var xs []Node
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokOr || tok.kind == tokRParen {
+			break
+		}
+		if tok.kind == tokAnd {
+			if len(xs) == 0 {
+				return nil, p.errorf("AND without preceding term")
+			}
+			p.pos++
+			continue
+		}
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		xs = append(xs, x)
+	}
+	switch len(xs) {
+	case 0:
+		return nil, p.errorf("expected search term")
+	case 1:
+		return xs[0], nil
+	}
+	return &And{Xs: xs}, nil
+}
+
+// parseUnary parses: [-] ( "(" or ")" | term )
+func (p *parser) parseUnary() (Node, error) {
+	tok, _ := p.peek()
+	p.pos++
+	switch tok.kind {
+	case tokMinus:
+		if _, ok := p.peek(); !ok {
+			return nil, p.errorf("expected search term after -")
+		}
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{X: x}, nil
+
+	case tokLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok, ok := p.peek(); !ok || tok.kind != tokRParen {
+			return nil, p.errorf("missing )")
+		}
+		p.pos++
+		return x, nil
+
+	case tokWord, tokPhrase:
+		return p.term(tok)
+	}
+	return nil, p.errorf("unexpected %s", tok.describe())
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokRParen:
+		return ")"
+	case tokOr:
+		return "OR"
+	case tokAnd:
+		return "AND"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+func (p *parser) term(tok token) (Node, error) {
+	switch tok.field {
+	case "path":
+		return &Path{Prefix: tok.text}, nil
+
+	case "tag":
+		tag := strings.TrimPrefix(tok.text, "#")
+		if tag == "" {
+			return nil, p.errorf("empty tag")
+		}
+		return &Tag{Tag: strings.ToLower(tag)}, nil
+
+	case "has":
+		feature := strings.ToLower(tok.text)
+		for _, f := range Features {
+			if f == feature {
+				return &Has{Feature: feature}, nil
+			}
+		}
+		return nil, p.errorf("unknown has: value %q (supported: %s)", tok.text, strings.Join(Features, ", "))
+
+	case "modified":
+		return p.modified(tok.text)
+
+	case "meta":
+		return p.meta(tok.text)
+	}
+	if tok.text == "" {
+		return nil, p.errorf("empty phrase")
+	}
+	return &Term{
+		Text:   strings.ToLower(tok.text),
+		Phrase: tok.kind == tokPhrase,
+	}, nil
+}
+
+// dateLayouts lists the accepted formats for modified: filters,
+// from most to least specific.
+var dateLayouts = []struct {
+	layout string
+	unit   func(time.Time) time.Time // returns the start of the next unit
+}{
+	{"2006-01-02T15:04:05", func(t time.Time) time.Time { return t.Add(time.Second) }},
+	{"2006-01-02T15:04", func(t time.Time) time.Time { return t.Add(time.Minute) }},
+	{"2006-01-02", func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+	{"2006-01", func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+	{"2006", func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+}
+
+func (p *parser) modified(value string) (Node, error) {
+	var op string
+	for _, candidate := range []string{">=", "<=", ">", "<", "="} {
+		if rest, ok := strings.CutPrefix(value, candidate); ok {
+			op, value = candidate, rest
+			break
+		}
+	}
+	for _, dl := range dateLayouts {
+		start, err := time.ParseInLocation(dl.layout, value, time.Local)
+		if err != nil {
+			continue
+		}
+		// end is the start of the next day (or minute, month, …),
+		// so that e.g. modified:2026-01-02 matches the entire day.
+		end := dl.unit(start)
+		switch op {
+		case ">":
+			return &Modified{After: end}, nil
+		case ">=":
+			return &Modified{After: start}, nil
+		case "<":
+			return &Modified{Before: start}, nil
+		case "<=":
+			return &Modified{Before: end}, nil
+		default:
+			return &Modified{After: start, Before: end}, nil
+		}
+	}
+	return nil, p.errorf("invalid date %q in modified: filter (use e.g. 2026-01-02)", value)
+}
+
+func (p *parser) meta(value string) (Node, error) {
+	idx := strings.IndexAny(value, "=<>")
+	if idx == -1 {
+		return &Meta{Key: value}, nil
+	}
+	key, rest := value[:idx], value[idx:]
+	if key == "" {
+		return nil, p.errorf("missing key in meta: filter %q (use e.g. meta:author=jane)", value)
+	}
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if value, ok := strings.CutPrefix(rest, op); ok {
+			if op == "=" {
+				value = strings.ToLower(value)
+			}
+			return &Meta{Key: key, Op: op, Value: value}, nil
+		}
+	}
+	return nil, p.errorf("invalid meta: filter %q", value)
+}
+
+// Parse parses a search query.
+func Parse(query string) (*Query, error) {
+	p := &parser{
+		query:  query,
+		tokens: lex(query),
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, p.errorf("unexpected %s", tok.describe())
+	}
+	return &Query{
+		raw:  query,
+		root: root,
+	}, nil
+}
+
+// ParseRegexp parses a regular expression query, see
+// https://golang.org/s/re2syntax for the syntax. Unlike other queries,
+// regular expression queries are case-sensitive unless they start with
+// (?i). The ^ and $ operators match at line boundaries.
+func ParseRegexp(expr string) (*Query, error) {
+	re, err := regexp.Compile("(?m)" + expr)
+	if err != nil {
+		msg := err.Error()
+		if rerr, ok := err.(*syntax.Error); ok {
+			msg = rerr.Code.String() + ": " + rerr.Expr
+		}
+		return nil, &SyntaxError{Query: expr, Msg: msg}
+	}
+	var literals []string
+	if parsed, err := syntax.Parse(expr, syntax.Perl); err == nil {
+		literals = requiredLiterals(parsed.Simplify())
+	}
+	return &Query{
+		raw:  expr,
+		root: &Regexp{Re: re, literals: literals},
+	}, nil
+}
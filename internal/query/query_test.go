@@ -0,0 +1,262 @@
+package query
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type testDoc struct {
+	name     string
+	text     string
+	modified time.Time
+	tags     []string
+	features []string
+	meta     map[string]string
+}
+
+func (d *testDoc) Name() string        { return d.name }
+func (d *testDoc) Text() string        { return d.text }
+func (d *testDoc) Modified() time.Time { return d.modified }
+
+func (d *testDoc) HasTag(tag string) bool {
+	for _, t := range d.tags {
+		if t == tag || strings.HasPrefix(t, tag+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *testDoc) Has(feature string) bool { return slices.Contains(d.features, feature) }
+
+func (d *testDoc) Meta(key string) (string, bool) {
+	value, ok := d.meta[key]
+	return value, ok
+}
+
+func TestMatch(t *testing.T) {
+	docs := []*testDoc{
+		{
+			name:     "days/2026-01-01",
+			text:     "Happy new year!\n- [ ] buy Milk",
+			modified: time.Date(2026, 1, 1, 23, 59, 0, 0, time.Local),
+			features: []string{"task"},
+		},
+		{
+			name:     "projects/bull",
+			text:     "bull is a minimalist bullet journaling program",
+			modified: time.Date(2026, 3, 15, 10, 0, 0, 0, time.Local),
+			tags:     []string{"project/bull"},
+			meta:     map[string]string{"author": "Michael", "date": "2026-03-01"},
+		},
+		{
+			name:     "recipes/milk rice",
+			text:     "Milk, rice, a year of patience",
+			modified: time.Date(2025, 12, 24, 18, 0, 0, 0, time.Local),
+			tags:     []string{"food"},
+			meta:     map[string]string{"date": "2025-12-24"},
+		},
+	}
+	for _, tt := range []struct {
+		query string
+		want  []string
+	}{
+		{query: "milk", want: []string{"days/2026-01-01", "recipes/milk rice"}},
+		{query: "MILK year", want: []string{"days/2026-01-01", "recipes/milk rice"}},
+		{query: "milk AND year", want: []string{"days/2026-01-01", "recipes/milk rice"}},
+		{query: "milk -rice", want: []string{"days/2026-01-01"}},
+		{query: `"new year"`, want: []string{"days/2026-01-01"}},
+		{query: `-"new year" year`, want: []string{"recipes/milk rice"}},
+		{query: "journaling OR patience", want: []string{"projects/bull", "recipes/milk rice"}},
+		{query: "bull OR milk -rice", want: []string{"days/2026-01-01", "projects/bull"}},
+		{query: "(bull OR milk) -rice", want: []string{"days/2026-01-01", "projects/bull"}},
+		{query: "path:recipes/", want: []string{"recipes/milk rice"}},
+		{query: `path:"recipes/milk "`, want: []string{"recipes/milk rice"}},
+		{query: "-path:days/ -path:recipes/", want: []string{"projects/bull"}},
+		{query: "tag:#project", want: []string{"projects/bull"}},
+		{query: "tag:project/bull", want: []string{"projects/bull"}},
+		{query: "tag:proj"},
+		{query: "has:task", want: []string{"days/2026-01-01"}},
+		{query: "modified:2026-01-01", want: []string{"days/2026-01-01"}},
+		{query: "modified:>2026-01-01", want: []string{"projects/bull"}},
+		{query: "modified:>=2026-01-01", want: []string{"days/2026-01-01", "projects/bull"}},
+		{query: "modified:<2026-01-01", want: []string{"recipes/milk rice"}},
+		{query: "modified:<=2026-01", want: []string{"days/2026-01-01", "recipes/milk rice"}},
+		{query: "modified:2025", want: []string{"recipes/milk rice"}},
+		{query: "meta:date", want: []string{"projects/bull", "recipes/milk rice"}},
+		{query: "meta:author=michael", want: []string{"projects/bull"}},
+		{query: "meta:author=jane"},
+		{query: "meta:date>=2026-01", want: []string{"projects/bull"}},
+		{query: "meta:date<2026", want: []string{"recipes/milk rice"}},
+		{query: "-meta:date", want: []string{"days/2026-01-01"}},
+		{query: "TODO:", want: nil},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, doc := range docs {
+				if q.Match(doc) {
+					got = append(got, doc.name)
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Match(%s): unexpected diff (-want +got):\n%s", tt.query, diff)
+			}
+
+			// MatchIndexed must not contradict Match.
+			for _, doc := range docs {
+				mayContain := func(term string) bool {
+					return strings.Contains(strings.ToLower(doc.text), term)
+				}
+				want := NoMatch
+				if q.Match(doc) {
+					want = Match
+				}
+				if got := q.MatchIndexed(noText{doc}, mayContain); got != want && got != Unknown {
+					t.Errorf("MatchIndexed(%s, %s) = %v, want %v or %v", tt.query, doc.name, got, want, Unknown)
+				}
+			}
+		})
+	}
+}
+
+// noText is a Doc whose content must not be looked at.
+type noText struct{ *testDoc }
+
+func (noText) Text() string { panic("Text called") }
+
+func TestMatchIndexed(t *testing.T) {
+	doc := &testDoc{
+		name:     "recipes/milk rice",
+		tags:     []string{"food"},
+		features: []string{"task"},
+	}
+	for _, tt := range []struct {
+		query      string
+		mayContain bool
+		want       Result
+	}{
+		{query: "milk", want: Match}, // page name
+		{query: "-milk", want: NoMatch},
+		{query: "flour", mayContain: true, want: Unknown},
+		{query: "flour", want: NoMatch},
+		{query: "-flour", want: Match},
+		{query: "flour OR rice", mayContain: true, want: Match},
+		{query: "flour rice", mayContain: true, want: Unknown},
+		{query: "path:recipes/", want: Match},
+		{query: "path:days/ flour", mayContain: true, want: NoMatch},
+		{query: "tag:food has:task", want: Match},
+		{query: "-tag:food", want: NoMatch},
+		{query: "meta:author OR flour", mayContain: true, want: Unknown},
+	} {
+		q, err := Parse(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mayContain := func(string) bool { return tt.mayContain }
+		if got := q.MatchIndexed(noText{doc}, mayContain); got != tt.want {
+			t.Errorf("MatchIndexed(%s, mayContain=%v) = %v, want %v", tt.query, tt.mayContain, got, tt.want)
+		}
+	}
+
+	q, err := ParseRegexp("mil+k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.MatchIndexed(noText{doc}, nil), Match; got != want {
+		t.Errorf("MatchIndexed(regexp) = %v, want %v", got, want)
+	}
+	doc.name = "recipes/pancakes"
+	if got, want := q.MatchIndexed(noText{doc}, nil), Unknown; got != want {
+		t.Errorf("MatchIndexed(regexp) = %v, want %v", got, want)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	q, err := Parse(`Foo "bar baz" -qux (a OR b) path:days/`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"foo", "bar baz", "a", "b"}, q.Terms()); diff != "" {
+		t.Errorf("Terms(): unexpected diff (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"foo", "bar baz"}, q.Required()); diff != "" {
+		t.Errorf("Required(): unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"OR foo",
+		"foo OR",
+		"AND foo",
+		"(foo",
+		"foo)",
+		`""`,
+		"has:magic",
+		"modified:yesterday",
+		"tag:#",
+		"meta:=x",
+	} {
+		t.Run(query, func(t *testing.T) {
+			if _, err := Parse(query); err == nil {
+				t.Errorf("Parse(%q) unexpectedly succeeded", query)
+			}
+		})
+	}
+}
+
+func TestParseRegexp(t *testing.T) {
+	docs := []*testDoc{
+		{name: "todo", text: "TODO(stapelberg): write tests\nTODO: nothing"},
+		{name: "days/2026-01-01", text: "Happy new year!"},
+		{name: "notes", text: "todo(lowercase)"},
+	}
+	for _, tt := range []struct {
+		expr     string
+		want     []string
+		required []string
+	}{
+		{expr: `TODO\(\w+\)`, want: []string{"todo"}, required: []string{"todo(", ")"}},
+		{expr: `(?i)TODO\(\w+\)`, want: []string{"todo", "notes"}, required: []string{"todo(", ")"}},
+		{expr: `\d{4}-\d{2}-\d{2}`, want: []string{"days/2026-01-01"}, required: []string{"-", "-"}},
+		{expr: `^TODO:`, want: []string{"todo"}, required: []string{"todo:"}},
+		{expr: `year!$`, want: []string{"days/2026-01-01"}, required: []string{"year!"}},
+		{expr: `(new|old) (year)+`, want: []string{"days/2026-01-01"}, required: []string{" ", "year"}},
+		{expr: `(?i)happy( new)? year`, want: []string{"days/2026-01-01"}, required: []string{"happy", " year"}},
+	} {
+		t.Run(tt.expr, func(t *testing.T) {
+			q, err := ParseRegexp(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.Regexp() == nil {
+				t.Fatalf("Regexp() = nil")
+			}
+			var got []string
+			for _, doc := range docs {
+				if q.Match(doc) {
+					got = append(got, doc.name)
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Match(%s): unexpected diff (-want +got):\n%s", tt.expr, diff)
+			}
+			if diff := cmp.Diff(tt.required, q.Required()); diff != "" {
+				t.Errorf("Required(%s): unexpected diff (-want +got):\n%s", tt.expr, diff)
+			}
+		})
+	}
+
+	if _, err := ParseRegexp(`TODO(`); err == nil {
+		t.Errorf("ParseRegexp unexpectedly succeeded for invalid expression")
+	}
+}